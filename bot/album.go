@@ -0,0 +1,107 @@
+package bot
+
+import (
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go-tg-support-ticket/form"
+	"go-tg-support-ticket/logger"
+	"go-tg-support-ticket/session"
+	"time"
+)
+
+// defaultAlbumDebounce is how long to wait for more files of a media group
+// before answering. Telegram sends the files of an album within a few hundred ms.
+const defaultAlbumDebounce = 1500 * time.Millisecond
+
+// album collects the outcome of the files of one media group, so the user
+// gets one answer for the whole album
+type album struct {
+	id       string
+	timer    *time.Timer
+	accepted int      // Files added to the uploads
+	rejected []string // Distinct messages for rejected files, in order
+}
+
+// collectAlbum records the outcome of one file of a media group and answers
+// once no more files of it arrived for the album debounce
+func (b *Bot) collectAlbum(s *session.Session, groupID string, userMsg string) {
+	chatID := s.ChatID
+
+	var a *album
+	if v, ok := b.albums.Load(chatID); ok {
+		a = v.(*album)
+		if a.id != groupID {
+			// A new album started before the previous one was answered
+			b.flushAlbum(chatID, a.id)
+			a = nil
+		}
+	}
+	if a == nil {
+		a = &album{id: groupID}
+		b.albums.Store(chatID, a)
+	}
+
+	if userMsg == "" {
+		a.accepted++
+	} else if !contains(a.rejected, userMsg) {
+		a.rejected = append(a.rejected, userMsg)
+	}
+
+	if a.timer != nil {
+		a.timer.Stop()
+	}
+	a.timer = time.AfterFunc(b.albumDebounce, func() {
+		// Run on the chat's worker so it cannot interleave with its updates;
+		// waits for a busy chat instead of never answering the album
+		b.dispatcher.SubmitWait(chatID, func() {
+			b.flushAlbum(chatID, groupID)
+		})
+	})
+}
+
+// flushAlbum answers the album of the chat if it is still the one collected
+func (b *Bot) flushAlbum(chatID int64, groupID string) {
+	v, ok := b.albums.Load(chatID)
+	if !ok || v.(*album).id != groupID {
+		return
+	}
+	a := v.(*album)
+	a.timer.Stop()
+	b.albums.Delete(chatID)
+
+	s, ok := b.getSession(chatID)
+	if !ok {
+		return
+	}
+	for _, text := range a.rejected {
+		if _, err := b.api.Send(tgbotapi.NewMessage(chatID, text)); err != nil {
+			logger.PrintLog(chatID, "failed to send validation error message", err)
+		}
+	}
+	if a.accepted == 0 {
+		return
+	}
+
+	// The user may have left the file field meanwhile, e.g. with /back
+	field, ok := s.CurrentInput()
+	if !ok || field.Type != form.FileType {
+		return
+	}
+
+	text := s.Form.Messages.FileUploadSuccess
+	if a.accepted > 1 {
+		text = fmt.Sprintf(s.Form.Messages.AlbumUploadSuccess, a.accepted)
+	}
+	if _, err := b.api.Send(tgbotapi.NewMessage(chatID, text)); err != nil {
+		logger.PrintLog(chatID, "failed to send success message", err)
+	}
+	b.sendUploadPrompt(s, field)
+}
+
+// flushAlbums answers all albums still waiting for their debounce
+func (b *Bot) flushAlbums() {
+	b.albums.Range(func(chatID, v any) bool {
+		b.flushAlbum(chatID.(int64), v.(*album).id)
+		return true
+	})
+}
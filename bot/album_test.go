@@ -0,0 +1,46 @@
+package bot
+
+import (
+	"go-tg-support-ticket/session"
+	"testing"
+)
+
+func TestCollectAlbum(t *testing.T) {
+	b := &Bot{dispatcher: newDispatcher(1, 10), albumDebounce: defaultAlbumDebounce}
+	defer b.dispatcher.Stop()
+	s := &session.Session{ChatID: 42}
+
+	// Three accepted files and two rejected for the same reason
+	for _, userMsg := range []string{"", "too large", "", "too large", ""} {
+		b.collectAlbum(s, "album-1", userMsg)
+	}
+
+	v, ok := b.albums.Load(s.ChatID)
+	if !ok {
+		t.Fatal("expected the album to be collected")
+	}
+	a := v.(*album)
+	if a.accepted != 3 {
+		t.Errorf("expected 3 accepted files, got %d", a.accepted)
+	}
+	if len(a.rejected) != 1 {
+		t.Errorf("expected 1 distinct rejection, got %v", a.rejected)
+	}
+
+	// A new album answers the previous one right away
+	b.collectAlbum(s, "album-2", "")
+	v, _ = b.albums.Load(s.ChatID)
+	if a2 := v.(*album); a2.id != "album-2" || a2.accepted != 1 {
+		t.Errorf("expected a new album with 1 file, got %s with %d", a2.id, a2.accepted)
+	}
+
+	// Flushing a stale album leaves the current one alone
+	b.flushAlbum(s.ChatID, "album-1")
+	if _, ok := b.albums.Load(s.ChatID); !ok {
+		t.Error("expected the current album to be kept")
+	}
+	b.flushAlbums()
+	if _, ok := b.albums.Load(s.ChatID); ok {
+		t.Error("expected all albums to be flushed")
+	}
+}
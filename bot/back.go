@@ -0,0 +1,47 @@
+package bot
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go-tg-support-ticket/logger"
+	"go-tg-support-ticket/session"
+)
+
+// handleBackCommand handles /back
+func (b *Bot) handleBackCommand(chatID int64) {
+	s, ok := b.getSession(chatID)
+	if !ok {
+		logger.PrintLog(chatID, "back requested without an active session", nil)
+		return
+	}
+	b.resetInactivityTimer(chatID)
+	b.goBack(s)
+}
+
+// goBack returns to the previous question. While modifying a field from
+// the review, it cancels the modification instead.
+func (b *Bot) goBack(s *session.Session) {
+	if s.Modifying != "" {
+		s.Modifying = ""
+		b.sendReviewMessage(s)
+		return
+	}
+
+	if !s.Back() {
+		if _, err := b.api.Send(tgbotapi.NewMessage(s.ChatID, s.Form.Messages.NoPreviousStep)); err != nil {
+			logger.PrintLog(s.ChatID, "failed to send no previous step message", err)
+		}
+		return
+	}
+	b.generateFormStep(s)
+}
+
+// backRow returns the back button row if the form shows one and there is
+// somewhere to go back to
+func backRow(s *session.Session) [][]tgbotapi.InlineKeyboardButton {
+	if !s.Form.BackButton || !s.CanGoBack() {
+		return nil
+	}
+	return [][]tgbotapi.InlineKeyboardButton{tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(s.Form.Messages.BackButton, "back"),
+	)}
+}
@@ -1,87 +1,270 @@
 package bot
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"go-tg-support-ticket/form"
 	"go-tg-support-ticket/internal/store"
 	"go-tg-support-ticket/logger"
+	"go-tg-support-ticket/session"
 	"go-tg-support-ticket/webhook"
+	"net/http"
 	"os"
-	"regexp"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
 )
 
 type Config struct {
-	Token string `mapstructure:"token" validate:"required"`
+	Token        string        `mapstructure:"token" validate:"required"`
+	Mode         string        `mapstructure:"mode"`          // "polling" (default) or "webhook"
+	Webhook      WebhookConfig `mapstructure:"webhook"`       // Settings for the "webhook" mode
+	SessionStore string        `mapstructure:"session_store"` // "memory", "file" or "database"
+	SessionDir   string        `mapstructure:"session_dir"`   // Directory of the session files for the "file" store
+	Workers      int           `mapstructure:"workers"`       // Number of update handler workers
+	QueueSize    int           `mapstructure:"queue_size"`    // Queued updates per worker before new ones wait for room
+	MetricsAddr  string        `mapstructure:"metrics_addr"`  // Serves /debug/vars on this address if set
+	FormMenu     string        `mapstructure:"form_menu"`     // Text of the form menu /start shows when several forms are served
 }
 
 type Bot struct {
-	api                   *tgbotapi.BotAPI
-	format                *form.Form
-	userStates            sync.Map // Stores user step (int64 -> int)
-	userModificationState sync.Map // Tracks modifying field (int64 -> string)
-	userTimers            sync.Map // Stores user inactivity timers (int64 -> *time.Timer)
-	sessionTimeout        time.Duration
-	authLinks             sync.Map // Authentication links (int64 -> string)
-	userAuthStatus        sync.Map // Auth status (int64 -> bool)
-	mu                    sync.Mutex
+	api            API
+	cfg            *Config
+	server         *http.Server          // Receives updates in webhook mode
+	metricsServer  *http.Server          // Serves /debug/vars if metrics_addr is set
+	dispatcher     *dispatcher           // Runs update handlers, sharded by chat
+	forms          []*form.Form          // Forms in menu order
+	formsBySlug    map[string]*form.Form // Forms by their slug
+	sessions       sync.Map              // Stores per-chat form sessions (int64 -> *session.Session)
+	sessionStore   session.Store
+	userTimers     sync.Map      // Stores user inactivity timers (int64 -> *time.Timer)
+	albums         sync.Map      // Media groups being received (int64 -> *album)
+	albumDebounce  time.Duration // Wait for more files of a media group before answering
+	sessionTimeout time.Duration
+	authLinks      sync.Map // Authentication links (int64 -> string)
+	userAuthStatus sync.Map // Auth status (int64 -> bool)
+	mu             sync.Mutex
 }
 
-func NewBot(cfg *Config, format *form.Form) (*Bot, error) {
+func NewBot(cfg *Config, forms []*form.Form) (*Bot, error) {
 	api, err := tgbotapi.NewBotAPI(cfg.Token)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create a new bot: %w", err)
 	}
+	return NewBotWithAPI(cfg, forms, api)
+}
+
+// NewBotWithAPI creates a bot that talks to Telegram through api, e.g. the
+// fake server of the bottest package
+func NewBotWithAPI(cfg *Config, forms []*form.Form, api API) (*Bot, error) {
+	if len(forms) == 0 {
+		return nil, fmt.Errorf("at least one form is required")
+	}
+	if errs := form.ValidateForms(forms); len(errs) > 0 {
+		return nil, errs[0]
+	}
+
+	sessionStore, err := newSessionStore(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session store: %w", err)
+	}
 
 	b := &Bot{
 		api:            api,
-		format:         format,
+		cfg:            cfg,
+		forms:          forms,
+		formsBySlug:    make(map[string]*form.Form, len(forms)),
+		sessionStore:   sessionStore,
+		albumDebounce:  defaultAlbumDebounce,
 		sessionTimeout: 30 * time.Minute,
+		dispatcher:     newDispatcher(cfg.Workers, cfg.QueueSize),
+	}
+	for _, f := range forms {
+		b.formsBySlug[f.Slug] = f
 	}
+	activeDispatcher.Store(b.dispatcher)
 
 	if err := b.SetCommands(); err != nil {
 		return nil, fmt.Errorf("failed to set commands: %w", err)
 	}
+
+	if err := b.restoreSessions(); err != nil {
+		return nil, fmt.Errorf("failed to restore sessions: %w", err)
+	}
+	if cfg.MetricsAddr != "" {
+		b.metricsServer = serveMetrics(cfg.MetricsAddr)
+	}
 	return b, nil
 }
 
-func (b *Bot) Start() {
+// Start receives updates via long polling or webhook, depending on the
+// configured mode, and hands them to the dispatcher until ctx is done.
+// Call Shutdown afterwards to wait for the handlers.
+func (b *Bot) Start(ctx context.Context) error {
+	switch b.cfg.Mode {
+	case "", "polling":
+	case "webhook":
+		stopped, err := b.startWebhook()
+		if err != nil {
+			return err
+		}
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			b.stopWebhook()
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown bot mode %q, must be 'polling' or 'webhook'", b.cfg.Mode)
+	}
+
+	// Telegram refuses getUpdates while a webhook is set
+	if _, err := b.api.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
-
 	updates := b.api.GetUpdatesChan(u)
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				return nil
+			}
+			b.dispatchPolled(update)
+		case <-ctx.Done():
+			b.stopPolling(updates)
+			return nil
+		}
+	}
+}
 
-	for update := range updates {
-		if update.Message != nil { // If we got a message
-			if update.Message.IsCommand() {
-				command := update.Message.Command()
-				switch command {
-				case "start":
-					b.userStates.Store(update.Message.Chat.ID, 0)
-					b.generateFormStep(update.Message.Chat.ID)
-				case "end":
-					b.endSession(update.Message.Chat.ID)
-				case "help":
-					b.sendHelpMessage(update.Message.Chat.ID)
-				default:
-					b.handleUserInput(update)
-				}
-			} else {
+// stopPolling stops taking new updates and dispatches the ones already received.
+// Updates of a long poll still running are not confirmed to Telegram, so
+// they are delivered again on the next start.
+func (b *Bot) stopPolling(updates tgbotapi.UpdatesChannel) {
+	b.api.StopReceivingUpdates()
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			b.dispatchPolled(update)
+		default:
+			return
+		}
+	}
+}
+
+// Shutdown waits for the handlers of dispatched updates, stops the inactivity
+// timers and persists all sessions, so they can be restored on the next start.
+// If ctx is done before the handlers finish, sessions are not persisted here,
+// as running handlers may still change them.
+func (b *Bot) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		b.dispatcher.Stop()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("update handlers did not finish: %w", ctx.Err())
+	}
+
+	b.userTimers.Range(func(_, timer any) bool {
+		timer.(*time.Timer).Stop()
+		return true
+	})
+	if err == nil {
+		// No handler runs anymore, so pending albums can be answered and
+		// sessions saved without racing a handler that holds them
+		b.flushAlbums()
+		b.sessions.Range(func(chatID, _ any) bool {
+			b.saveSession(chatID.(int64))
+			return true
+		})
+	}
+	if b.metricsServer != nil {
+		if serr := b.metricsServer.Shutdown(ctx); serr != nil {
+			logger.PrintLog(0, "failed to stop metrics server gracefully", serr)
+			b.metricsServer.Close()
+		}
+	}
+	return err
+}
+
+// dispatch hands the update to the worker of its chat. It waits up to
+// submitTimeout for room in a full queue and returns false if the update
+// was dropped.
+func (b *Bot) dispatch(update tgbotapi.Update) bool {
+	return b.dispatcher.Submit(updateChatID(update), func() {
+		b.handleUpdate(update)
+	}, submitTimeout)
+}
+
+// dispatchPolled dispatches an update received by long polling. Telegram
+// does not deliver it again, so the user is asked to resend a dropped one.
+func (b *Bot) dispatchPolled(update tgbotapi.Update) {
+	if b.dispatch(update) {
+		return
+	}
+	if chatID := updateChatID(update); chatID != 0 {
+		if _, err := b.api.Send(tgbotapi.NewMessage(chatID, busyMessage)); err != nil {
+			logger.PrintLog(chatID, "failed to send busy message", err)
+		}
+	}
+}
+
+// updateChatID returns the chat of the update, or 0 for updates without one
+func updateChatID(update tgbotapi.Update) int64 {
+	if chat := update.FromChat(); chat != nil {
+		return chat.ID
+	}
+	return 0
+}
+
+// handleUpdate routes a single update and persists the resulting session state
+func (b *Bot) handleUpdate(update tgbotapi.Update) {
+	if update.Message != nil { // If we got a message
+		if update.Message.IsCommand() {
+			command := update.Message.Command()
+			switch command {
+			case "start":
+				b.handleStart(update.Message.Chat.ID, update.Message.From, update.Message.CommandArguments())
+			case "back":
+				b.handleBackCommand(update.Message.Chat.ID)
+			case "end":
+				b.endSession(update.Message.Chat.ID, webhook.SessionEnded)
+			case "help":
+				b.sendHelpMessage(update.Message.Chat.ID)
+			default:
 				b.handleUserInput(update)
 			}
-		} else if update.CallbackQuery != nil { // If we got a callback query
-			b.handleCallbackQuery(update)
+		} else {
+			b.handleUserInput(update)
 		}
+	} else if update.CallbackQuery != nil { // If we got a callback query
+		b.handleCallbackQuery(update)
+	}
+
+	if chat := update.FromChat(); chat != nil {
+		b.saveSession(chat.ID)
 	}
 }
 
 func (b *Bot) sendHelpMessage(chatID int64) {
 	helpText := `Welcome to the bot! Here are the available commands:
 /start - Start a new session
+/start <form> - Start the given form
+/back - Go back to the previous question
 /end - End the current session
 /help - Show this help message`
 	if _, err := b.api.Send(tgbotapi.NewMessage(chatID, helpText)); err != nil {
@@ -92,6 +275,7 @@ func (b *Bot) sendHelpMessage(chatID int64) {
 func (b *Bot) SetCommands() error {
 	commands := []tgbotapi.BotCommand{
 		{Command: "start", Description: "Start a new session"},
+		{Command: "back", Description: "Go back to the previous question"},
 		{Command: "end", Description: "End the current session"},
 		{Command: "help", Description: "Show help message"},
 	}
@@ -100,20 +284,53 @@ func (b *Bot) SetCommands() error {
 	return err
 }
 
-func (b *Bot) generateFormStep(chatID int64) {
-	stepA, _ := b.userStates.LoadOrStore(chatID, 0)
-	step := stepA.(int)
+// startSession begins a new session of form f for the chat and user,
+// discarding any previous one
+func (b *Bot) startSession(chatID int64, user *tgbotapi.User, f *form.Form) {
+	if previous, ok := b.getSession(chatID); ok {
+		data := progress(previous)
+		data["reason"] = webhook.EndedByRestart
+		b.publish(previous, webhook.SessionEnded, data)
+	}
+
+	s := session.New(chatID, f)
+	s.User = sessionUser(user)
+	b.sessions.Store(chatID, s)
+	b.resetInactivityTimer(chatID)
+	b.publish(s, webhook.SessionStarted, nil)
+	b.generateFormStep(s)
+}
 
-	if step >= len(b.format.Fields) {
-		if b.format.ReviewEnabled {
-			b.sendReviewMessage(chatID, b.format)
+// getSession returns the active session of the chat, if any
+func (b *Bot) getSession(chatID int64) (*session.Session, bool) {
+	s, ok := b.sessions.Load(chatID)
+	if !ok {
+		return nil, false
+	}
+	return s.(*session.Session), true
+}
+
+func (b *Bot) generateFormStep(s *session.Session) {
+	chatID := s.ChatID
+
+	field, ok := s.CurrentField()
+	if !ok {
+		if s.Form.ReviewEnabled {
+			b.sendReviewMessage(s)
 		} else {
-			b.submitForm(chatID)
+			b.submitForm(s)
 		}
 		return
 	}
 
-	field := b.format.Fields[step]
+	// Between the items of a group, ask whether to add another one
+	if field.Type == form.GroupType {
+		if !s.InItem {
+			b.sendGroupPrompt(s, field)
+			return
+		}
+		field, _ = s.CurrentInput()
+	}
 
 	// Function to apply message formatting
 	applyFormatting := func(msg *tgbotapi.MessageConfig) {
@@ -137,6 +354,9 @@ func (b *Bot) generateFormStep(chatID int64) {
 		// Send a text message if no valid type found
 		msg := tgbotapi.NewMessage(chatID, field.Description)
 		applyFormatting(&msg)
+		if field.UsesReplyKeyboard() {
+			msg.ReplyMarkup = replyKeyboard(s.Form.Messages, field)
+		}
 
 		_, err = b.api.Send(msg)
 	}
@@ -152,6 +372,14 @@ func (b *Bot) generateFormStep(chatID int64) {
 		return
 	}
 
+	if field.Type == form.DateType && field.Calendar {
+		b.sendCalendar(s, field)
+	}
+	if field.Type == form.MultiselectType {
+		b.sendMultiselect(s, field)
+		return
+	}
+
 	// Add buttons for the field
 	var rows [][]tgbotapi.InlineKeyboardButton
 	for _, button := range field.Buttons {
@@ -163,13 +391,26 @@ func (b *Bot) generateFormStep(chatID int64) {
 	// Add a "Skip" button if the field is skippable
 	if field.Skippable {
 		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData(b.format.Messages.SkipButton, "skip"),
+			tgbotapi.NewInlineKeyboardButtonData(s.Form.Messages.SkipButton, "skip"),
 		))
 	}
 
+	// Offer to keep the answer given before the user went back
+	text := s.Form.Messages.ChooseOption
+	if previous, ok := s.Previous(); ok {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(s.Form.Messages.KeepButton, "keep"),
+		))
+		text = fmt.Sprintf(s.Form.Messages.CurrentAnswer, previous)
+		if len(field.Buttons) > 0 {
+			text += "\n" + s.Form.Messages.ChooseOption
+		}
+	}
+	rows = append(rows, backRow(s)...)
+
 	// Send inline buttons if there are any
 	if len(rows) > 0 {
-		msg := tgbotapi.NewMessage(chatID, b.format.Messages.ChooseOption)
+		msg := tgbotapi.NewMessage(chatID, text)
 		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
 		if _, err := b.api.Send(msg); err != nil {
 			logger.PrintLog(chatID, "failed to send inline keyboard", err)
@@ -177,11 +418,23 @@ func (b *Bot) generateFormStep(chatID int64) {
 	}
 }
 
-func (b *Bot) sendReviewMessage(chatID int64, config *form.Form) {
+func (b *Bot) sendReviewMessage(s *session.Session) {
+	chatID := s.ChatID
+	fields := s.VisibleFields()
+
 	var reviewText strings.Builder
-	reviewText.WriteString(b.format.Messages.Review + "\n\n")
-	for _, field := range config.Fields {
+	reviewText.WriteString(s.Form.Messages.Review + "\n\n")
+	for _, field := range fields {
 		value := field.UserValue
+		if field.Type == form.GroupType {
+			value = formatGroupValue(field)
+		} else if field.Type == form.LocationType || field.Type == form.ContactType {
+			value = formatSharedValue(field)
+		} else if field.Type == form.MultiselectType {
+			value = formatSelections(field)
+		} else if field.Type == form.FileType {
+			value = formatFiles(field)
+		}
 		if value == "" {
 			value = "Not provided"
 		}
@@ -190,17 +443,18 @@ func (b *Bot) sendReviewMessage(chatID int64, config *form.Form) {
 
 	// Add buttons for each field to allow modification
 	var rows [][]tgbotapi.InlineKeyboardButton
-	for _, field := range config.Fields {
+	for _, field := range fields {
 		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf(b.format.Messages.ModifyButton, field.Label), fmt.Sprintf("modify_%s", field.Name)),
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf(s.Form.Messages.ModifyButton, field.Label), fmt.Sprintf("modify_%s", field.Name)),
 		))
 	}
 
 	// Add a submit button
 	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
 		//tgbotapi.NewInlineKeyboardButtonData("✅ Submit", "submit"),
-		tgbotapi.NewInlineKeyboardButtonData(b.format.Messages.SubmitButton, "submit"),
+		tgbotapi.NewInlineKeyboardButtonData(s.Form.Messages.SubmitButton, "submit"),
 	))
+	rows = append(rows, backRow(s)...)
 
 	msg := tgbotapi.NewMessage(chatID, reviewText.String())
 	msg.ParseMode = tgbotapi.ModeHTML
@@ -210,14 +464,15 @@ func (b *Bot) sendReviewMessage(chatID int64, config *form.Form) {
 	}
 }
 
-func (b *Bot) submitForm(chatID int64) {
+func (b *Bot) submitForm(s *session.Session) {
+	chatID := s.ChatID
 
-	if err := store.Tickets.Create(b.format.TableName, b.format.Fields); err != nil {
+	if err := store.Tickets.Create(s); err != nil {
 		logger.PrintLog(chatID, "failed to create form", err)
 	}
 
 	//text := "🎉 Thank you for submitting the form! 🎉"
-	text := b.format.Messages.Submit
+	text := s.Form.Messages.Submit
 
 	// Process the form submission (e.g., save to database)
 	msg := tgbotapi.NewMessage(chatID, text)
@@ -226,7 +481,7 @@ func (b *Bot) submitForm(chatID int64) {
 	}
 
 	if webhook.Workers != nil {
-		webhook.Workers.Enqueue(b.format)
+		webhook.Workers.Enqueue(s)
 	}
 
 	// Clear the user session after submission
@@ -234,8 +489,10 @@ func (b *Bot) submitForm(chatID int64) {
 }
 
 func (b *Bot) clearUserSession(chatID int64) {
-	b.userStates.Delete(chatID)
-	b.userModificationState.Delete(chatID)
+	b.sessions.Delete(chatID)
+	if err := b.sessionStore.Delete(chatID); err != nil {
+		logger.PrintLog(chatID, "failed to delete stored session", err)
+	}
 	if timer, ok := b.userTimers.Load(chatID); ok {
 		timer.(*time.Timer).Stop()
 		b.userTimers.Delete(chatID)
@@ -243,76 +500,64 @@ func (b *Bot) clearUserSession(chatID int64) {
 }
 
 func (b *Bot) resetInactivityTimer(chatID int64) {
+	if s, ok := b.getSession(chatID); ok {
+		s.Deadline = time.Now().Add(b.sessionTimeout)
+	}
+	b.startInactivityTimer(chatID, b.sessionTimeout)
+}
+
+// startInactivityTimer ends the session of the chat once d has passed without activity
+func (b *Bot) startInactivityTimer(chatID int64, d time.Duration) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
 	if timer, ok := b.userTimers.Load(chatID); ok {
 		timer.(*time.Timer).Stop()
 	}
-	timer := time.AfterFunc(b.sessionTimeout, func() {
-		b.endSession(chatID)
+	timer := time.AfterFunc(d, func() {
+		// Run on the chat's worker so it cannot interleave with its updates;
+		// waits for a busy chat instead of never timing out
+		b.dispatcher.SubmitWait(chatID, func() {
+			b.endSession(chatID, webhook.SessionTimedOut)
+		})
 	})
 	b.userTimers.Store(chatID, timer)
 }
 
-// validateField validates the user input for a field based on its validation rules.
-func (b *Bot) validateField(field form.Field, text string) (string, error) {
-	value := text
-
-	// Skip validation if the field is skippable and the value is empty
-	if field.Skippable && value == "" {
-		return "", nil
-	}
-
-	// Check if the field is required and the value is empty
-	if field.Required && value == "" {
-		//userMsg := fmt.Sprintf("Oops! The input for %s is required. Please provide a value.", field.Name)
-		userMsg := fmt.Sprintf(b.format.Messages.RequiredInput, field.Name)
-		logMsg := fmt.Errorf("validation error for %s: input is required but was not provided", field.Name)
-		return userMsg, logMsg
-	}
-
-	// Validate based on the field type
-	switch field.Type {
-	case "text":
-		return b.validateTextField(field, value)
-	case "number":
-		return b.validateNumberField(field, value)
-	case "email":
-		return b.validateEmailField(field, value)
-	case "select":
-		return b.validateSelectField(field, value)
-	case "file":
-		return b.validateFileField(field, value)
-	default:
-		userMsg := fmt.Sprintf("Oops! Unsupport field type")
-		logMsg := fmt.Errorf("unsupported field type: %s", field.Type)
-		return userMsg, logMsg
-	}
-}
-
 // handleUserInput handles the input for each field
 func (b *Bot) handleUserInput(update tgbotapi.Update) {
 	chatID := update.Message.Chat.ID
 
+	s, ok := b.getSession(chatID)
+	if !ok {
+		logger.PrintLog(chatID, "input received without an active session", nil)
+		return
+	}
+
 	// Reset the inactivity timer for the user
 	b.resetInactivityTimer(chatID)
 
 	// Handle file uploads (photos, documents, videos)
 	if update.Message.Document != nil || update.Message.Photo != nil || update.Message.Video != nil {
-		b.handleFileUpload(update)
+		b.handleFileUpload(s, update)
+		return
+	}
+
+	// Handle shared contacts, locations and venues
+	if update.Message.Contact != nil || update.Message.Location != nil || update.Message.Venue != nil {
+		b.handleSharedInput(s, update.Message)
 		return
 	}
 
 	text := update.Message.Text
 
 	// Check if the user is modifying a field
-	if fieldName, ok := b.userModificationState.Load(chatID); ok {
-		for i, field := range b.format.Fields {
-			if field.Name == fieldName {
+	if s.Modifying != "" {
+		for _, field := range s.Form.Fields {
+			if field.Name == s.Modifying {
 
 				// Validate the user input
-				if msg, err := b.validateField(field, text); err != nil {
+				if msg, err := validateField(s.Form, field, text); err != nil {
 					msg := tgbotapi.NewMessage(chatID, msg)
 					if _, err := b.api.Send(msg); err != nil {
 						logger.PrintLog(chatID, "failed to send error message", err)
@@ -321,23 +566,21 @@ func (b *Bot) handleUserInput(update tgbotapi.Update) {
 				}
 
 				// Store the validated input
-				b.format.Fields[i].UserValue = text
+				s.SetAnswer(field.Name, normalizeInput(s.Form, field, text))
+				if field.UsesReplyKeyboard() {
+					b.removeReplyKeyboard(s)
+				}
 				break
 			}
 		}
-		b.userModificationState.Delete(chatID) // Clear modification state
-		b.sendReviewMessage(chatID, b.format)
+		b.finishModification(s)
 		return
 	}
 
 	// Handle input for the current step
-	stepA, _ := b.userStates.Load(chatID)
-	step := stepA.(int)
-	if step < len(b.format.Fields) {
-		field := b.format.Fields[step]
-
+	if field, ok := s.CurrentInput(); ok {
 		// Validate the user input
-		if msg, err := b.validateField(field, text); err != nil {
+		if msg, err := validateField(s.Form, field, text); err != nil {
 			logger.PrintLog(chatID, "user input validation", err)
 			msg := tgbotapi.NewMessage(chatID, msg)
 			if _, err := b.api.Send(msg); err != nil {
@@ -347,14 +590,39 @@ func (b *Bot) handleUserInput(update tgbotapi.Update) {
 		}
 
 		// Store the validated input
-		b.format.Fields[step].UserValue = text
-		b.userStates.Store(chatID, step+1)
-		b.generateFormStep(chatID)
+		b.record(s, normalizeInput(s.Form, field, text))
+		if field.UsesReplyKeyboard() {
+			b.removeReplyKeyboard(s)
+		}
+		b.generateFormStep(s)
 	}
 }
 
-// endSession allows users to end their current session
-func (b *Bot) endSession(chatID int64) {
+// finishModification ends the modification of a field from the review
+func (b *Bot) finishModification(s *session.Session) {
+	if field, ok := s.FieldByName(s.Modifying); ok {
+		b.publish(s, webhook.ReviewModified, map[string]interface{}{"field": field.Name, "value": webhook.FieldValue(s, field, s.Answer(field.Name))})
+	}
+	s.Modifying = "" // Clear modification state
+
+	// The new answer may lead the flow to fields that were not asked yet
+	if s.Resume() {
+		b.generateFormStep(s)
+		return
+	}
+	b.sendReviewMessage(s)
+}
+
+// endSession allows users to end their current session. eventType is
+// session.ended or session.timed_out for the webhook receivers.
+func (b *Bot) endSession(chatID int64, eventType string) {
+	if s, ok := b.getSession(chatID); ok {
+		data := progress(s)
+		if eventType == webhook.SessionEnded {
+			data["reason"] = webhook.EndedByCommand
+		}
+		b.publish(s, eventType, data)
+	}
 	b.clearUserSession(chatID)
 	msg := tgbotapi.NewMessage(chatID, "Your session has been ended. Thank you for using the bot.")
 	if _, err := b.api.Send(msg); err != nil {
@@ -367,245 +635,201 @@ func (b *Bot) handleCallbackQuery(update tgbotapi.Update) {
 	query := update.CallbackQuery
 	chatID := query.Message.Chat.ID
 
+	// Picking a form from the menu works without an active session
+	if slug, ok := strings.CutPrefix(query.Data, formMenuPrefix); ok {
+		b.handleFormChoice(chatID, query.From, slug)
+		return
+	}
+
+	s, ok := b.getSession(chatID)
+	if !ok {
+		logger.PrintLog(chatID, "callback received without an active session", nil)
+		return
+	}
+
 	// Reset the inactivity timer for the user
 	b.resetInactivityTimer(chatID)
 
 	switch {
 	case query.Data == "skip":
-		if stepValue, ok := b.userStates.Load(chatID); ok {
-			step := stepValue.(int)
-			if step < len(b.format.Fields) {
-				b.format.Fields[step].UserValue = "skipped"
-				b.userStates.Store(chatID, step+1)
-				b.generateFormStep(chatID)
+		if field, ok := s.CurrentInput(); ok {
+			b.skip(s)
+			if field.UsesReplyKeyboard() {
+				b.removeReplyKeyboard(s)
 			}
+			b.generateFormStep(s)
 		}
 	case query.Data == "submit":
-		b.submitForm(chatID)
+		b.submitForm(s)
+	case strings.HasPrefix(query.Data, calendarPrefix):
+		b.handleCalendar(s, query)
+	case strings.HasPrefix(query.Data, multiselectPrefix):
+		b.handleMultiselect(s, query)
+	case query.Data == "back":
+		b.goBack(s)
+	case query.Data == "keep":
+		field, _ := s.CurrentInput()
+		if value, ok := s.Previous(); ok {
+			b.record(s, value)
+			if field.UsesReplyKeyboard() {
+				b.removeReplyKeyboard(s)
+			}
+			b.generateFormStep(s)
+		}
+	case query.Data == "add_item":
+		b.handleAddItem(s)
+	case query.Data == "finish_group":
+		b.handleFinishGroup(s)
 	case strings.HasPrefix(query.Data, "modify_"):
 		fieldName := strings.TrimPrefix(query.Data, "modify_")
-		b.userModificationState.Store(chatID, fieldName) // Set the field to modify
+		if b.modifyGroup(s, fieldName) {
+			return
+		}
+		s.Modifying = fieldName // Set the field to modify
 		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Please enter a new value for %s:", fieldName))
+		if field, ok := s.FieldByName(fieldName); ok && field.UsesReplyKeyboard() {
+			msg.ReplyMarkup = replyKeyboard(s.Form.Messages, field)
+		}
 		if _, err := b.api.Send(msg); err != nil {
 			logger.PrintLog(chatID, "failed to send modify message", err)
 		}
 	case query.Data == "upload_another":
 		// User wants to upload another file, so we continue the current form step
 		//msg := tgbotapi.NewMessage(chatID, "Please upload another file.")
-		msg := tgbotapi.NewMessage(chatID, b.format.Messages.UploadAnother)
+		msg := tgbotapi.NewMessage(chatID, s.Form.Messages.UploadAnother)
 		if _, err := b.api.Send(msg); err != nil {
 			logger.PrintLog(chatID, "failed to send upload prompt message", err)
 		}
 	case query.Data == "finish_uploading":
 		// User finished uploading, continue with the form
-		st, _ := b.userStates.Load(chatID)
-		b.userStates.Store(chatID, st.(int)+1) // Move to the next step
-		b.generateFormStep(chatID)
-	default:
-		if stepValue, ok := b.userStates.Load(chatID); ok {
-			step := stepValue.(int)
-			if step < len(b.format.Fields) {
-				b.format.Fields[step].UserValue = query.Data
-				b.userStates.Store(chatID, step+1)
-				b.generateFormStep(chatID)
-			}
+		field, ok := s.CurrentInput()
+		if !ok {
+			return
 		}
-	}
-}
-
-// validateTextField validates a text field with both user and log messages.
-func (b *Bot) validateTextField(field form.Field, value string) (string, error) {
-	// Check min and max length (if specified)
-	if field.Validation.MinLength > 0 && len(value) < field.Validation.MinLength {
-		//userMsg := fmt.Sprintf("Oops! The input for %s is too short. Please provide at least %d characters.", field.Label, field.Validation.MinLength)
-		userMsg := fmt.Sprintf(b.format.Messages.InvalidMinLength, field.Label, field.Validation.MinLength)
-		logMsg := fmt.Errorf("validation error for %s: input is too short. Expected at least %d characters, got %d characters", field.Label, field.Validation.MinLength, len(value))
-		return userMsg, logMsg
-	}
-	if field.Validation.MaxLength > 0 && len(value) > field.Validation.MaxLength {
-		//userMsg := fmt.Sprintf("Oops! The input for %s is too long. Please provide no more than %d characters.", field.Label, field.Validation.MaxLength)
-		userMsg := fmt.Sprintf(b.format.Messages.InvalidMaxLength, field.Label, field.Validation.MaxLength)
-		logMsg := fmt.Errorf("validation error for %s: input is too long. Expected no more than %d characters, got %d characters", field.Label, field.Validation.MaxLength, len(value))
-		return userMsg, logMsg
-	}
-
-	// Check regex pattern (if specified)
-	if field.Validation.Regex != "" {
-		matched, err := regexp.MatchString(field.Validation.Regex, value)
+		uploads, err := json.Marshal(s.Files)
 		if err != nil {
-			//userMsg := fmt.Sprintf("Oops! Something went wrong while validating your input for %s. Please try again.", field.Label)
-			userMsg := fmt.Sprintf(b.format.Messages.ValidationError, field.Label)
-			logMsg := fmt.Errorf("regex error for %s: %v", field.Label, err)
-			return userMsg, logMsg
+			logger.PrintLog(chatID, "failed to encode uploaded files", err)
+			return
 		}
-		if !matched {
-			//userMsg := fmt.Sprintf("Oops! The input for %s doesn't match the required format. Please make sure it’s correct.", field.Label)
-			userMsg := fmt.Sprintf(b.format.Messages.InvalidFormat, field.Label)
-			logMsg := fmt.Errorf("validation error for %s: input '%s' does not match required regex '%s'", field.Label, value, field.Validation.Regex)
-			return userMsg, logMsg
+		if userMsg, err := validateField(s.Form, field, string(uploads)); err != nil {
+			logger.PrintLog(chatID, "user input validation", err)
+			if _, err := b.api.Send(tgbotapi.NewMessage(chatID, userMsg)); err != nil {
+				logger.PrintLog(chatID, "failed to send validation error message", err)
+			}
+			return
 		}
-	}
-
-	return "", nil
-}
-
-// validateNumberField validates a number field with both user and log messages.
-func (b *Bot) validateNumberField(field form.Field, value string) (string, error) {
-	// Convert the value to a number
-	num, err := strconv.Atoi(value)
-	if err != nil {
-		//userMsg := fmt.Sprintf("Oops! The input for %s must be a valid number. Please provide a valid number.", field.Label)
-		userMsg := fmt.Sprintf(b.format.Messages.InvalidNumber, field.Label)
-		logMsg := fmt.Errorf("validation error for %s: failed to convert '%s' to a number. Error: %v", field.Label, value, err)
-		return userMsg, logMsg
-	}
-
-	// Check min and max values (if specified)
-	if field.Validation.Min > 0 && num < field.Validation.Min {
-		//userMsg := fmt.Sprintf("Oops! The input for %s must be at least %d. Please provide a valid number.", field.Label, field.Validation.Min)
-		userMsg := fmt.Sprintf(b.format.Messages.InvalidMinNumber, field.Label, field.Validation.Min)
-		logMsg := fmt.Errorf("validation error for %s: input %d is less than the minimum %d", field.Label, num, field.Validation.Min)
-		return userMsg, logMsg
-	}
-	if field.Validation.Max > 0 && num > field.Validation.Max {
-		//userMsg := fmt.Sprintf("Oops! The input for %s must be at most %d. Please provide a valid number.", field.Label, field.Validation.Max)
-		userMsg := fmt.Sprintf(b.format.Messages.InvalidMaxNumber, field.Label, field.Validation.Max)
-		logMsg := fmt.Errorf("validation error for %s: input %d exceeds the maximum %d", field.Label, num, field.Validation.Max)
-		return userMsg, logMsg
-	}
-
-	return "", nil
-}
-
-// validateEmailField validates an email field with both user and log messages.
-func (b *Bot) validateEmailField(field form.Field, value string) (string, error) {
-	// Simple email regex for validation
-	emailRegex := `^[a-z0-9]+@[a-z0-9]+\.[a-z]{2,3}$`
-	matched, err := regexp.MatchString(emailRegex, value)
-	if err != nil {
-		//userMsg := fmt.Sprintf("Oops! Something went wrong while validating your email. Please try again.")
-		userMsg := fmt.Sprintf(b.format.Messages.InvalidEmail)
-		logMsg := fmt.Errorf("email validation error for %s: regex failed with error: %v", field.Label, err)
-		return userMsg, logMsg
-	}
-	if !matched {
-		//userMsg := fmt.Sprintf("Oops! The input for %s doesn't look like a valid email address. Please check and try again.", field.Label)
-		userMsg := fmt.Sprintf(b.format.Messages.InvalidEmail)
-		logMsg := fmt.Errorf("validation error for %s: email '%s' does not match valid format", field.Label, value)
-		return userMsg, logMsg
-	}
-	return "", nil
-}
-
-// validateSelectField validates a select field with both user and log messages.
-func (b *Bot) validateSelectField(field form.Field, value string) (string, error) {
-	// Check if the value is one of the allowed options
-	for _, option := range field.Options {
-		if value == option {
-			return "", nil
+		s.Files = nil
+		b.record(s, string(uploads)) // Move to the next step
+		b.generateFormStep(s)
+	default:
+		if _, ok := s.CurrentInput(); ok {
+			b.record(s, query.Data)
+			b.generateFormStep(s)
 		}
 	}
-	//userMsg := fmt.Sprintf("Oops! The input for %s must be one of the following options: %s. Please choose one.", field.Label, strings.Join(field.Options, ", "))
-	userMsg := fmt.Sprintf(b.format.Messages.ChooseOption, field.Label, strings.Join(field.Options, ", "))
-	logMsg := fmt.Errorf("validation error for %s: invalid option '%s'. Expected one of: %s", field.Label, value, strings.Join(field.Options, ", "))
-	return userMsg, logMsg
-}
-
-// validateFileField validates a file field with both user and log messages.
-func (b *Bot) validateFileField(field form.Field, value string) (string, error) {
-	// For file fields, we can check if the value is a valid file path or URL
-	if value == "" && field.Required {
-		//userMsg := fmt.Sprintf("Oops! The input for %s is required. Please upload a file.", field.Label)
-		userMsg := fmt.Sprintf(b.format.Messages.RequiredFile, field.Label)
-		logMsg := fmt.Errorf("validation error for %s: file is required but was not provided", field.Label)
-		return userMsg, logMsg
-	}
-	return "", nil
 }
 
 // handleFileUpload handles the file upload logic for multiple files
-func (b *Bot) handleFileUpload(update tgbotapi.Update) {
+func (b *Bot) handleFileUpload(s *session.Session, update tgbotapi.Update) {
 	chatID := update.Message.Chat.ID
 
 	// Get the current step
-	stepA, _ := b.userStates.Load(chatID)
-	step := stepA.(int)
-	if step >= len(b.format.Fields) {
+	field, ok := s.CurrentInput()
+	if !ok {
 		return
 	}
 
-	field := b.format.Fields[step]
-
 	// Ensure the field is of type "file"
-	if field.Type != "file" {
+	if field.Type != form.FileType {
 		logger.PrintLog(chatID, "invalid field type for file upload", fmt.Errorf("expected file type, got %s", field.Type))
 		return
 	}
 
-	// Initialize a variable to store the highest resolution file URL
-	var fileURL string
-
-	// Process documents
-	if update.Message.Document != nil {
-		// Handle a single document (document is always one file)
-		fileURL, _ = b.api.GetFileDirectURL(update.Message.Document.FileID)
-	}
-
-	// Process photos (get the highest resolution)
-	if len(update.Message.Photo) > 0 {
-		// Telegram provides multiple photo resolutions, so we take the highest one (last one)
-		// Location array is sorted by resolution (first is the smallest, last is the largest)
-		photo := update.Message.Photo[len(update.Message.Photo)-1] // Take the last one (highest resolution)
-		fileURL, _ = b.api.GetFileDirectURL(photo.FileID)
-	}
-
-	// Process videos (handle video uploads)
-	if update.Message.Video != nil {
-		// Handle a single video (only one file)
-		fileURL, _ = b.api.GetFileDirectURL(update.Message.Video.FileID)
-	}
-
-	// If no valid files are found, log an error and return
-	if fileURL == "" {
+	// Take the document, photo or video of the message
+	file, ok := messageFile(update.Message)
+	if !ok {
 		logger.PrintLog(chatID, "no valid file found in the message", fmt.Errorf("no valid file found"))
 		return
 	}
 
-	// Append the file URL to the current field's user value (multiple files allowed)
-	if field.UserValue == "" {
-		field.UserValue = fileURL
-	} else {
-		field.UserValue += "," + fileURL // Separate multiple file URLs with a comma
+	// Files of an album arrive as separate updates; answer them together
+	userMsg := b.receiveFile(s, field, file)
+	if groupID := update.Message.MediaGroupID; groupID != "" {
+		b.collectAlbum(s, groupID, userMsg)
+		return
 	}
 
-	// Update the field in the form
-	b.format.Fields[step].UserValue = field.UserValue
+	if userMsg != "" {
+		if _, err := b.api.Send(tgbotapi.NewMessage(chatID, userMsg)); err != nil {
+			logger.PrintLog(chatID, "failed to send validation error message", err)
+		}
+		return
+	}
 
 	// Notify the user that the file was uploaded successfully
 	//msg := tgbotapi.NewMessage(chatID, "File uploaded successfully!")
-	msg := tgbotapi.NewMessage(chatID, b.format.Messages.FileUploadSuccess)
+	msg := tgbotapi.NewMessage(chatID, s.Form.Messages.FileUploadSuccess)
 	if _, err := b.api.Send(msg); err != nil {
 		logger.PrintLog(chatID, "failed to send success message", err)
 	}
+	b.sendUploadPrompt(s, field)
+}
+
+// receiveFile checks the file, copies it to the storage and adds it to the
+// uploads of the session. It returns the message for the user if the file
+// was rejected.
+func (b *Bot) receiveFile(s *session.Session, field form.Field, file form.File) string {
+	chatID := s.ChatID
+
+	// Reject files over the limits before anything is downloaded
+	userMsg, err := checkFile(s.Form.Messages, field, file, uploadLimit(field))
+	if err == nil && field.Validation.MaxFiles > 0 && len(s.Files) >= field.Validation.MaxFiles {
+		userMsg = fmt.Sprintf(s.Form.Messages.TooManyFiles, field.Validation.MaxFiles, field.Label)
+		err = fmt.Errorf("validation error for %s: already %d files uploaded", field.Label, len(s.Files))
+	}
+	if err != nil {
+		logger.PrintLog(chatID, "user input validation", err)
+		return userMsg
+	}
+
+	// Copy the file to the storage; its download URL contains the bot token
+	file, err = b.storeFile(s, field, file)
+	if err != nil {
+		logger.PrintLog(chatID, "failed to store uploaded file", err)
+		if errors.Is(err, errFileTooLarge) {
+			return fmt.Sprintf(s.Form.Messages.FileTooLarge, field.Label, uploadLimit(field))
+		}
+		return s.Form.Messages.FileUploadFailed
+	}
 
-	// Prompt the user to either upload another file or finish uploading
+	// Append the file to the session upload buffer (multiple files allowed)
+	s.Files = append(s.Files, file)
+	return ""
+}
+
+// sendUploadPrompt asks the user to either upload another file or finish uploading
+func (b *Bot) sendUploadPrompt(s *session.Session, field form.Field) {
 	// Add buttons for the field
 	var rows [][]tgbotapi.InlineKeyboardButton
-	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+	buttons := []tgbotapi.InlineKeyboardButton{
 		//tgbotapi.NewInlineKeyboardButtonData("Upload another", "upload_another"),
 		//tgbotapi.NewInlineKeyboardButtonData("Finish uploading", "finish_uploading"),
-		tgbotapi.NewInlineKeyboardButtonData(b.format.Messages.UploadAnotherButton, "upload_another"),
-		tgbotapi.NewInlineKeyboardButtonData(b.format.Messages.FinishUploadButton, "finish_uploading"),
-	))
+		tgbotapi.NewInlineKeyboardButtonData(s.Form.Messages.UploadAnotherButton, "upload_another"),
+		tgbotapi.NewInlineKeyboardButtonData(s.Form.Messages.FinishUploadButton, "finish_uploading"),
+	}
+	if field.Validation.MaxFiles > 0 && len(s.Files) >= field.Validation.MaxFiles {
+		buttons = buttons[1:] // No room for another file
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(buttons...))
 
 	inlineKeyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
 
 	// Send the prompt message with buttons
 	//msgPrompt := tgbotapi.NewMessage(chatID, "Do you want to upload another file or finish uploading?")
-	msgPrompt := tgbotapi.NewMessage(chatID, b.format.Messages.FinishUpload)
+	msgPrompt := tgbotapi.NewMessage(s.ChatID, s.Form.Messages.FinishUpload)
 	msgPrompt.ReplyMarkup = inlineKeyboard
 	if _, err := b.api.Send(msgPrompt); err != nil {
-		logger.PrintLog(chatID, "failed to send upload prompt", err)
+		logger.PrintLog(s.ChatID, "failed to send upload prompt", err)
 	}
 }
 
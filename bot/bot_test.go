@@ -140,6 +140,24 @@ func TestValidateField(t *testing.T) {
 			userValue:   "",
 			expectError: false,
 		},
+		{
+			name:        "File field with typed text",
+			field:       form.Field{Name: "file", Type: "file"},
+			userValue:   "here is my file",
+			expectError: true,
+		},
+		{
+			name:        "File field with too few files",
+			field:       form.Field{Name: "file", Type: "file", Validation: form.Validation{MinFiles: 2}},
+			userValue:   `[{"file_id":"a","size":10}]`,
+			expectError: true,
+		},
+		{
+			name:        "File field with enough files",
+			field:       form.Field{Name: "file", Type: "file", Validation: form.Validation{MinFiles: 1, MaxFiles: 2}},
+			userValue:   `[{"file_id":"a","size":10},{"file_id":"b","size":20}]`,
+			expectError: false,
+		},
 		{
 			name: "Text field with no validation rules",
 			field: form.Field{
@@ -179,6 +197,106 @@ func TestValidateField(t *testing.T) {
 			userValue:   "",
 			expectError: true,
 		},
+		{
+			name:        "Valid date field",
+			field:       form.Field{Name: "due", Type: "date"},
+			userValue:   "31/12/2025",
+			expectError: false,
+		},
+		{
+			name:        "Invalid date field",
+			field:       form.Field{Name: "due", Type: "date"},
+			userValue:   "31/13/2025",
+			expectError: true,
+		},
+		{
+			name: "Date field before min_date",
+			field: form.Field{
+				Name:       "due",
+				Type:       "date",
+				Validation: form.Validation{MinDate: "today"},
+			},
+			userValue:   "2000-01-01",
+			expectError: true,
+		},
+		{
+			name: "Date field after relative max_date",
+			field: form.Field{
+				Name:       "due",
+				Type:       "date",
+				Validation: form.Validation{MaxDate: "today+30d"},
+			},
+			userValue:   "2999-01-01",
+			expectError: true,
+		},
+		{
+			name: "Time field within range",
+			field: form.Field{
+				Name:       "slot",
+				Type:       "time",
+				Validation: form.Validation{MinDate: "09:00", MaxDate: "17:00"},
+			},
+			userValue:   "2:30 PM",
+			expectError: false,
+		},
+		{
+			name: "Time field out of range",
+			field: form.Field{
+				Name:       "slot",
+				Type:       "time",
+				Validation: form.Validation{MinDate: "09:00", MaxDate: "17:00"},
+			},
+			userValue:   "18:00",
+			expectError: true,
+		},
+		{
+			name:        "Valid datetime field",
+			field:       form.Field{Name: "at", Type: "datetime"},
+			userValue:   "2025-12-31 14:30",
+			expectError: false,
+		},
+		{
+			name:        "Valid phone field",
+			field:       form.Field{Name: "phone", Type: "phone"},
+			userValue:   "+1 (415) 555-0123",
+			expectError: false,
+		},
+		{
+			name:        "Invalid phone field",
+			field:       form.Field{Name: "phone", Type: "phone"},
+			userValue:   "call me",
+			expectError: true,
+		},
+		{
+			name:        "Typed location field",
+			field:       form.Field{Name: "where", Type: "location"},
+			userValue:   "Bangkok",
+			expectError: true,
+		},
+		{
+			name:        "Valid multiselect field",
+			field:       form.Field{Name: "tags", Type: "multiselect", Options: []string{"Red", "Green", "Blue"}},
+			userValue:   "red, Blue",
+			expectError: false,
+		},
+		{
+			name:        "Invalid multiselect option",
+			field:       form.Field{Name: "tags", Type: "multiselect", Options: []string{"Red", "Green", "Blue"}},
+			userValue:   "Red, Purple",
+			expectError: true,
+		},
+		{
+			name:        "Too many multiselect options",
+			field:       form.Field{Name: "tags", Type: "multiselect", Options: []string{"Red", "Green", "Blue"}, Validation: form.Validation{MaxItems: 1}},
+			userValue:   "Red, Green",
+			expectError: true,
+		},
+		{
+			name:        "Too few multiselect options",
+			field:       form.Field{Name: "tags", Type: "multiselect", Options: []string{"Red", "Green", "Blue"}, Validation: form.Validation{MinItems: 2}},
+			userValue:   "Green",
+			expectError: true,
+		},
 	}
 
 	for _, test := range tests {
@@ -0,0 +1,546 @@
+// Package bottest provides an in-process fake of the Telegram Bot API, so
+// whole conversations with the bot can be tested without network access.
+//
+// The server answers the Bot API methods the bot uses, records every message
+// the bot sends and hands out injected updates through getUpdates:
+//
+//	srv := bottest.NewServer()
+//	defer srv.Close()
+//	api, _ := srv.API()
+//	b, _ := bot.NewBotWithAPI(cfg, forms, api)
+//	go b.Start(ctx)
+//
+//	srv.SendText(42, "/start")
+//	msg, err := srv.WaitForText(42, "What is your name?", time.Second)
+package bottest
+
+import (
+	"encoding/json"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Token is the bot token the fake server accepts
+const Token = "123456:TEST-TOKEN"
+
+// Identity of the fake bot returned by getMe
+const (
+	BotID       = 123456
+	BotUsername = "test_bot"
+)
+
+// defaultPollTimeout bounds how long getUpdates waits for new updates, so
+// the bot notices a shutdown quickly
+const defaultPollTimeout = 100 * time.Millisecond
+
+// defaultClickTimeout bounds how long Click waits for the bot to send the
+// button
+const defaultClickTimeout = 2 * time.Second
+
+// Button is a button of an inline keyboard
+type Button struct {
+	Text string
+	Data string // callback_data
+}
+
+// Message is a call of the bot that shows something in a chat, such as
+// sendMessage, editMessageReplyMarkup or answerCallbackQuery
+type Message struct {
+	Method    string     // Bot API method, e.g. "sendMessage"
+	ChatID    int64      // Chat the message was sent to
+	MessageID int        // ID of the sent or edited message
+	Text      string     // Text, caption or callback answer
+	Buttons   [][]Button // Inline keyboard
+	Keyboard  [][]string // Texts of the reply keyboard buttons
+	Params    url.Values // All parameters of the call
+}
+
+// Button returns the inline button with the text, if there is one
+func (m Message) Button(text string) (Button, bool) {
+	for _, row := range m.Buttons {
+		for _, button := range row {
+			if button.Text == text {
+				return button, true
+			}
+		}
+	}
+	return Button{}, false
+}
+
+// Server is a fake Telegram Bot API server
+type Server struct {
+	URL          string        // Base URL of the server
+	PollTimeout  time.Duration // Longest wait of getUpdates for new updates
+	ClickTimeout time.Duration // Longest wait of Click for the button
+
+	server *httptest.Server
+
+	mu            sync.Mutex
+	changed       chan struct{} // Closed and replaced whenever updates or messages are added
+	updates       []tgbotapi.Update
+	nextUpdateID  int
+	nextMessageID int
+	messages      []Message
+	read          map[int64]int    // Chat -> number of its messages returned by Next
+	callbacks     map[string]int64 // Callback query ID -> chat
+	files         map[string][]byte
+	calls         []string
+	stopped       time.Time // When a client stopped receiving updates
+}
+
+// NewServer starts a fake Bot API server. Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		PollTimeout:   defaultPollTimeout,
+		ClickTimeout:  defaultClickTimeout,
+		changed:       make(chan struct{}),
+		nextUpdateID:  1,
+		nextMessageID: 1,
+		read:          make(map[int64]int),
+		callbacks:     make(map[string]int64),
+		files:         make(map[string][]byte),
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.server.URL
+	return s
+}
+
+// Close shuts the server down
+func (s *Server) Close() {
+	// Let the last long poll of a stopped client end, so it does not fail on
+	// the closed connection
+	s.mu.Lock()
+	stopped := s.stopped
+	s.mu.Unlock()
+	if !stopped.IsZero() {
+		time.Sleep(time.Until(stopped.Add(2 * s.PollTimeout)))
+	}
+	s.server.CloseClientConnections()
+	s.server.Close()
+}
+
+// API returns a client of the fake server that satisfies bot.API
+func (s *Server) API() (*API, error) {
+	api, err := tgbotapi.NewBotAPIWithAPIEndpoint(Token, s.URL+"/bot%s/%s")
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the fake server: %w", err)
+	}
+	return &API{BotAPI: api, server: s}, nil
+}
+
+// API is a Bot API client of the fake server
+type API struct {
+	*tgbotapi.BotAPI
+	server *Server
+}
+
+// StopReceivingUpdates stops the long polling of the client
+func (a *API) StopReceivingUpdates() {
+	a.BotAPI.StopReceivingUpdates()
+	a.server.mu.Lock()
+	a.server.stopped = time.Now()
+	a.server.mu.Unlock()
+}
+
+// GetFileDirectURL returns the download URL of a file on the fake server
+func (a *API) GetFileDirectURL(fileID string) (string, error) {
+	file, err := a.GetFile(tgbotapi.FileConfig{FileID: fileID})
+	if err != nil {
+		return "", err
+	}
+	return a.server.URL + "/file/bot" + Token + "/" + file.FilePath, nil
+}
+
+// Calls returns the names of all Bot API methods called so far, in order
+func (s *Server) Calls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.calls...)
+}
+
+// Messages returns all messages sent to the chat so far
+func (s *Server) Messages(chatID int64) []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.chatMessages(chatID)
+}
+
+// Next waits for the next message to the chat that was not returned by Next
+// or WaitForText yet
+func (s *Server) Next(chatID int64, timeout time.Duration) (Message, error) {
+	return s.wait(chatID, timeout, func(Message) bool { return true })
+}
+
+// WaitForText waits for a message to the chat that contains text, skipping
+// earlier unread messages
+func (s *Server) WaitForText(chatID int64, text string, timeout time.Duration) (Message, error) {
+	m, err := s.wait(chatID, timeout, func(m Message) bool { return strings.Contains(m.Text, text) })
+	if err != nil {
+		return m, fmt.Errorf("waiting for %q: %w", text, err)
+	}
+	return m, nil
+}
+
+func (s *Server) wait(chatID int64, timeout time.Duration, match func(Message) bool) (Message, error) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		s.mu.Lock()
+		messages := s.chatMessages(chatID)
+		for i := s.read[chatID]; i < len(messages); i++ {
+			if match(messages[i]) {
+				s.read[chatID] = i + 1
+				s.mu.Unlock()
+				return messages[i], nil
+			}
+		}
+		s.read[chatID] = len(messages)
+		changed := s.changed
+		s.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-deadline.C:
+			return Message{}, fmt.Errorf("no matching message to chat %d within %s", chatID, timeout)
+		}
+	}
+}
+
+// chatMessages returns the messages of the chat; s.mu must be held
+func (s *Server) chatMessages(chatID int64) []Message {
+	var messages []Message
+	for _, m := range s.messages {
+		if m.ChatID == chatID {
+			messages = append(messages, m)
+		}
+	}
+	return messages
+}
+
+// notify wakes up waiting getUpdates calls and waiters; s.mu must be held
+func (s *Server) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// Send injects an update. Its update_id is assigned by the server.
+func (s *Server) Send(update tgbotapi.Update) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	update.UpdateID = s.nextUpdateID
+	s.nextUpdateID++
+	s.updates = append(s.updates, update)
+	s.notify()
+}
+
+// SendText injects a text message of the user of a private chat. Commands
+// like "/start help-desk" are marked as such.
+func (s *Server) SendText(chatID int64, text string) {
+	m := s.newMessage(chatID)
+	m.Text = text
+	if strings.HasPrefix(text, "/") {
+		command, _, _ := strings.Cut(text, " ")
+		m.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(command)}}
+	}
+	s.Send(tgbotapi.Update{Message: m})
+}
+
+// SendDocument injects a document of the user and serves its content for
+// getFile and downloads
+func (s *Server) SendDocument(chatID int64, name, mimeType string, data []byte) {
+	m := s.newMessage(chatID)
+	fileID := s.AddFile(data)
+	m.Document = &tgbotapi.Document{FileID: fileID, FileUniqueID: "u" + fileID, FileName: name, MimeType: mimeType, FileSize: len(data)}
+	s.Send(tgbotapi.Update{Message: m})
+}
+
+// SendPhoto injects a photo of the user. Set mediaGroupID to send it as a
+// part of an album.
+func (s *Server) SendPhoto(chatID int64, data []byte, mediaGroupID string) {
+	m := s.newMessage(chatID)
+	fileID := s.AddFile(data)
+	m.Photo = []tgbotapi.PhotoSize{{FileID: fileID, FileUniqueID: "u" + fileID, Width: 800, Height: 600, FileSize: len(data)}}
+	m.MediaGroupID = mediaGroupID
+	s.Send(tgbotapi.Update{Message: m})
+}
+
+// Click injects a press of the inline button with the callback data, waiting
+// up to ClickTimeout for the bot to send it. The query refers to the latest
+// message of the chat that has such a button.
+func (s *Server) Click(chatID int64, data string) error {
+	err := s.ClickFunc(chatID, func(b Button) bool { return b.Data == data })
+	if err != nil {
+		return fmt.Errorf("no button with data %q: %w", data, err)
+	}
+	return nil
+}
+
+// ClickText is Click for the button labelled text
+func (s *Server) ClickText(chatID int64, text string) error {
+	err := s.ClickFunc(chatID, func(b Button) bool { return b.Text == text })
+	if err != nil {
+		return fmt.Errorf("no button %q: %w", text, err)
+	}
+	return nil
+}
+
+// ClickFunc is Click for the first button match accepts
+func (s *Server) ClickFunc(chatID int64, match func(Button) bool) error {
+	deadline := time.NewTimer(s.ClickTimeout)
+	defer deadline.Stop()
+
+	for {
+		s.mu.Lock()
+		source, button, ok := s.findButton(chatID, match)
+		if ok {
+			id := strconv.Itoa(s.nextUpdateID)
+			s.callbacks[id] = chatID
+			s.mu.Unlock()
+
+			s.Send(tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+				ID:      id,
+				From:    user(chatID),
+				Message: source,
+				Data:    button.Data,
+			}})
+			return nil
+		}
+		changed := s.changed
+		s.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-deadline.C:
+			return fmt.Errorf("not sent to chat %d within %s", chatID, s.ClickTimeout)
+		}
+	}
+}
+
+// findButton returns the latest message of the chat with a matching inline
+// button; s.mu must be held
+func (s *Server) findButton(chatID int64, match func(Button) bool) (*tgbotapi.Message, Button, bool) {
+	messages := s.chatMessages(chatID)
+	for i := len(messages) - 1; i >= 0; i-- {
+		for _, row := range messages[i].Buttons {
+			for _, button := range row {
+				if match(button) {
+					return &tgbotapi.Message{MessageID: messages[i].MessageID, Chat: privateChat(chatID), Text: messages[i].Text}, button, true
+				}
+			}
+		}
+	}
+	return nil, Button{}, false
+}
+
+// AddFile stores a file on the server and returns its file_id
+func (s *Server) AddFile(data []byte) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fileID := fmt.Sprintf("file-%d", len(s.files)+1)
+	s.files[fileID] = data
+	return fileID
+}
+
+func (s *Server) newMessage(chatID int64) *tgbotapi.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := &tgbotapi.Message{
+		MessageID: s.nextMessageID,
+		From:      user(chatID),
+		Chat:      privateChat(chatID),
+		Date:      int(time.Now().Unix()),
+	}
+	s.nextMessageID++
+	return m
+}
+
+// In private chats the chat ID is the user ID
+func user(chatID int64) *tgbotapi.User {
+	return &tgbotapi.User{ID: chatID, FirstName: "Test", UserName: "user" + strconv.FormatInt(chatID, 10)}
+}
+
+func privateChat(chatID int64) *tgbotapi.Chat {
+	return &tgbotapi.Chat{ID: chatID, Type: "private"}
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if path, ok := strings.CutPrefix(r.URL.Path, "/file/bot"+Token+"/"); ok {
+		s.serveFile(w, path)
+		return
+	}
+
+	method, ok := strings.CutPrefix(r.URL.Path, "/bot"+Token+"/")
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	// Also parses url-encoded bodies; ErrNotMultipart is expected for those
+	_ = r.ParseMultipartForm(32 << 20)
+	params := r.Form
+
+	s.mu.Lock()
+	s.calls = append(s.calls, method)
+	s.mu.Unlock()
+
+	switch {
+	case method == "getMe":
+		writeResult(w, tgbotapi.User{ID: BotID, IsBot: true, FirstName: "Test Bot", UserName: BotUsername})
+	case method == "getUpdates":
+		writeResult(w, s.getUpdates(r, params))
+	case method == "getFile":
+		s.getFile(w, params.Get("file_id"))
+	case strings.HasPrefix(method, "send"), strings.HasPrefix(method, "edit"):
+		writeResult(w, s.record(method, params))
+	case method == "answerCallbackQuery":
+		s.record(method, params)
+		writeResult(w, true)
+	default:
+		// setMyCommands, deleteWebhook, deleteMessage, ...
+		writeResult(w, true)
+	}
+}
+
+// getUpdates returns the updates from offset on, waiting briefly for new ones
+func (s *Server) getUpdates(r *http.Request, params url.Values) []tgbotapi.Update {
+	offset, _ := strconv.Atoi(params.Get("offset"))
+	wait := time.NewTimer(s.PollTimeout)
+	defer wait.Stop()
+
+	for {
+		s.mu.Lock()
+		// Updates before the offset are confirmed and forgotten
+		var pending []tgbotapi.Update
+		for _, u := range s.updates {
+			if u.UpdateID >= offset {
+				pending = append(pending, u)
+			}
+		}
+		s.updates = pending
+		changed := s.changed
+		s.mu.Unlock()
+
+		if len(pending) > 0 {
+			return pending
+		}
+		select {
+		case <-changed:
+		case <-wait.C:
+			return []tgbotapi.Update{}
+		case <-r.Context().Done():
+			return []tgbotapi.Update{}
+		}
+	}
+}
+
+// record stores a message sent or edited by the bot and returns it as the
+// Bot API would
+func (s *Server) record(method string, params url.Values) tgbotapi.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m := Message{Method: method, Params: params}
+	m.ChatID, _ = strconv.ParseInt(params.Get("chat_id"), 10, 64)
+	if method == "answerCallbackQuery" {
+		m.ChatID = s.callbacks[params.Get("callback_query_id")]
+	}
+
+	m.Text = params.Get("text")
+	if m.Text == "" {
+		m.Text = params.Get("caption")
+	}
+
+	if id := params.Get("message_id"); id != "" {
+		m.MessageID, _ = strconv.Atoi(id)
+	} else {
+		m.MessageID = s.nextMessageID
+		s.nextMessageID++
+	}
+
+	var markup struct {
+		InlineKeyboard [][]struct {
+			Text         string `json:"text"`
+			CallbackData string `json:"callback_data"`
+		} `json:"inline_keyboard"`
+		Keyboard [][]struct {
+			Text string `json:"text"`
+		} `json:"keyboard"`
+	}
+	if err := json.Unmarshal([]byte(params.Get("reply_markup")), &markup); err == nil {
+		for _, row := range markup.InlineKeyboard {
+			var buttons []Button
+			for _, b := range row {
+				buttons = append(buttons, Button{Text: b.Text, Data: b.CallbackData})
+			}
+			m.Buttons = append(m.Buttons, buttons)
+		}
+		for _, row := range markup.Keyboard {
+			var texts []string
+			for _, b := range row {
+				texts = append(texts, b.Text)
+			}
+			m.Keyboard = append(m.Keyboard, texts)
+		}
+	}
+
+	// An edited keyboard replaces the buttons of the original message
+	if method == "editMessageReplyMarkup" {
+		for i := range s.messages {
+			if s.messages[i].ChatID == m.ChatID && s.messages[i].MessageID == m.MessageID && s.messages[i].Method != method {
+				m.Text = s.messages[i].Text
+				s.messages[i].Buttons = m.Buttons
+			}
+		}
+	}
+
+	s.messages = append(s.messages, m)
+	s.notify()
+
+	return tgbotapi.Message{
+		MessageID: m.MessageID,
+		Chat:      privateChat(m.ChatID),
+		Date:      int(time.Now().Unix()),
+		Text:      m.Text,
+	}
+}
+
+func (s *Server) getFile(w http.ResponseWriter, fileID string) {
+	s.mu.Lock()
+	data, ok := s.files[fileID]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Bad Request: invalid file_id")
+		return
+	}
+	writeResult(w, tgbotapi.File{FileID: fileID, FileUniqueID: "u" + fileID, FileSize: len(data), FilePath: "files/" + fileID})
+}
+
+func (s *Server) serveFile(w http.ResponseWriter, path string) {
+	s.mu.Lock()
+	data, ok := s.files[strings.TrimPrefix(path, "files/")]
+	s.mu.Unlock()
+	if !ok {
+		http.NotFound(w, nil)
+		return
+	}
+	w.Write(data)
+}
+
+func writeResult(w http.ResponseWriter, result interface{}) {
+	data, _ := json.Marshal(result)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tgbotapi.APIResponse{Ok: true, Result: data})
+}
+
+func writeError(w http.ResponseWriter, code int, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(tgbotapi.APIResponse{Ok: false, ErrorCode: code, Description: description})
+}
@@ -0,0 +1,154 @@
+package bot
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go-tg-support-ticket/form"
+	"go-tg-support-ticket/logger"
+	"go-tg-support-ticket/session"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// calendarPrefix marks callback data of the calendar keyboard
+const calendarPrefix = "cal:"
+
+const (
+	calendarIgnore = calendarPrefix + "ignore"
+	calendarNav    = calendarPrefix + "nav:"
+	calendarPick   = calendarPrefix + "pick:"
+)
+
+// sendCalendar offers a calendar for a "date" field, opened at the month of
+// the previous answer or the current month, kept within the bounds.
+func (b *Bot) sendCalendar(s *session.Session, field form.Field) {
+	now := time.Now().In(s.Form.Location())
+	min, max, err := field.DateBounds(now)
+	if err != nil {
+		logger.PrintLog(s.ChatID, "failed to resolve date bounds", err)
+		return
+	}
+
+	month := now
+	if previous, ok := s.Previous(); ok {
+		if t, err := time.ParseInLocation(form.DateLayout, previous, now.Location()); err == nil {
+			month = t
+		}
+	}
+	if min != nil && month.Before(*min) {
+		month = *min
+	}
+	if max != nil && month.After(*max) {
+		month = *max
+	}
+
+	msg := tgbotapi.NewMessage(s.ChatID, s.Form.Messages.PickDate)
+	msg.ReplyMarkup = calendarKeyboard(month, min, max)
+	if _, err := b.api.Send(msg); err != nil {
+		logger.PrintLog(s.ChatID, "failed to send calendar", err)
+	}
+}
+
+// handleCalendar handles the buttons of the calendar keyboard
+func (b *Bot) handleCalendar(s *session.Session, query *tgbotapi.CallbackQuery) {
+	// Stop the loading indicator, also for the buttons that do nothing
+	if _, err := b.api.Request(tgbotapi.NewCallback(query.ID, "")); err != nil {
+		logger.PrintLog(s.ChatID, "failed to answer calendar callback", err)
+	}
+
+	field, ok := s.CurrentInput()
+	if !ok || field.Type != form.DateType {
+		return
+	}
+	loc := s.Form.Location()
+
+	switch {
+	case strings.HasPrefix(query.Data, calendarNav):
+		month, err := time.ParseInLocation("2006-01", strings.TrimPrefix(query.Data, calendarNav), loc)
+		if err != nil {
+			logger.PrintLog(s.ChatID, "invalid calendar navigation", err)
+			return
+		}
+		min, max, err := field.DateBounds(time.Now().In(loc))
+		if err != nil {
+			logger.PrintLog(s.ChatID, "failed to resolve date bounds", err)
+			return
+		}
+		edit := tgbotapi.NewEditMessageReplyMarkup(s.ChatID, query.Message.MessageID, calendarKeyboard(month, min, max))
+		if _, err := b.api.Request(edit); err != nil {
+			logger.PrintLog(s.ChatID, "failed to update calendar", err)
+		}
+	case strings.HasPrefix(query.Data, calendarPick):
+		value := strings.TrimPrefix(query.Data, calendarPick)
+		if msg, err := validateField(s.Form, field, value); err != nil {
+			logger.PrintLog(s.ChatID, "user input validation", err)
+			if _, err := b.api.Send(tgbotapi.NewMessage(s.ChatID, msg)); err != nil {
+				logger.PrintLog(s.ChatID, "failed to send error message", err)
+			}
+			return
+		}
+		b.record(s, normalizeInput(s.Form, field, value))
+		b.generateFormStep(s)
+	}
+}
+
+// calendarKeyboard renders a month with navigation to the neighbouring
+// months. Days outside the bounds are shown but cannot be picked.
+func calendarKeyboard(month time.Time, min, max *time.Time) tgbotapi.InlineKeyboardMarkup {
+	first := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, month.Location())
+	last := first.AddDate(0, 1, -1)
+	ignore := func(text string) tgbotapi.InlineKeyboardButton {
+		return tgbotapi.NewInlineKeyboardButtonData(text, calendarIgnore)
+	}
+	outOfRange := func(day time.Time) bool {
+		return (min != nil && day.Before(startOfDay(*min))) || (max != nil && day.After(*max))
+	}
+
+	// Header with the month and the navigation
+	prev, next := ignore(" "), ignore(" ")
+	if min == nil || !first.AddDate(0, 0, -1).Before(startOfDay(*min)) {
+		prev = tgbotapi.NewInlineKeyboardButtonData("«", calendarNav+first.AddDate(0, -1, 0).Format("2006-01"))
+	}
+	if max == nil || !last.AddDate(0, 0, 1).After(*max) {
+		next = tgbotapi.NewInlineKeyboardButtonData("»", calendarNav+first.AddDate(0, 1, 0).Format("2006-01"))
+	}
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(prev, ignore(first.Format("January 2006")), next),
+	}
+
+	var weekdays []tgbotapi.InlineKeyboardButton
+	for _, name := range []string{"Mo", "Tu", "We", "Th", "Fr", "Sa", "Su"} {
+		weekdays = append(weekdays, ignore(name))
+	}
+	rows = append(rows, weekdays)
+
+	// Weeks start on Monday
+	var week []tgbotapi.InlineKeyboardButton
+	for i := 0; i < (int(first.Weekday())+6)%7; i++ {
+		week = append(week, ignore(" "))
+	}
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		if outOfRange(day) {
+			week = append(week, ignore("·"))
+		} else {
+			week = append(week, tgbotapi.NewInlineKeyboardButtonData(strconv.Itoa(day.Day()), calendarPick+day.Format(form.DateLayout)))
+		}
+		if len(week) == 7 {
+			rows = append(rows, week)
+			week = nil
+		}
+	}
+	if len(week) > 0 {
+		for len(week) < 7 {
+			week = append(week, ignore(" "))
+		}
+		rows = append(rows, week)
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// startOfDay returns midnight of the day of t
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,206 @@
+package bot
+
+import (
+	"expvar"
+	"fmt"
+	"go-tg-support-ticket/logger"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultWorkers   = 8
+	defaultQueueSize = 100
+)
+
+// submitTimeout bounds the wait for room in a full queue before an update is
+// dropped. Webhook updates are then refused, so Telegram sends them again.
+const submitTimeout = 5 * time.Second
+
+// busyMessage asks the user to resend a polled update that was dropped
+const busyMessage = "⏳ The bot is busy right now. Please send that again in a moment."
+
+// dispatcher runs jobs on a fixed pool of workers. Jobs are sharded by chat
+// ID, so jobs of one chat run in order while different chats run in parallel.
+// Every worker has a bounded queue; updates for a queue that stays full are
+// dropped.
+type dispatcher struct {
+	queues []chan func()
+	wg     sync.WaitGroup
+	mu     sync.RWMutex // Guards closed against concurrent Submit calls
+	closed bool
+
+	enqueued  atomic.Int64
+	processed atomic.Int64
+	dropped   atomic.Int64
+	panics    atomic.Int64
+	inFlight  atomic.Int64
+}
+
+// DispatcherStats is a snapshot of the dispatcher counters
+type DispatcherStats struct {
+	Workers   int   `json:"workers"`
+	Enqueued  int64 `json:"enqueued"`
+	Processed int64 `json:"processed"`
+	Dropped   int64 `json:"dropped"`
+	Panics    int64 `json:"panics"`
+	InFlight  int64 `json:"in_flight"`
+	Backlog   int64 `json:"backlog"`
+}
+
+// newDispatcher starts workers goroutines with a queue of queueSize jobs each
+func newDispatcher(workers, queueSize int) *dispatcher {
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	if queueSize <= 0 {
+		queueSize = defaultQueueSize
+	}
+
+	d := &dispatcher{queues: make([]chan func(), workers)}
+	for i := range d.queues {
+		d.queues[i] = make(chan func(), queueSize)
+		d.wg.Add(1)
+		go d.work(d.queues[i])
+	}
+	return d
+}
+
+// Submit queues job on the worker owning chatID. If the worker's queue is
+// full, it waits up to timeout for room. It returns false if the job was
+// dropped because the queue stayed full or the dispatcher is stopped.
+func (d *dispatcher) Submit(chatID int64, job func(), timeout time.Duration) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		d.dropped.Add(1)
+		return false
+	}
+
+	shard := d.shard(chatID)
+	select {
+	case d.queues[shard] <- job:
+		d.enqueued.Add(1)
+		return true
+	default:
+	}
+
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case d.queues[shard] <- job:
+			d.enqueued.Add(1)
+			return true
+		case <-timer.C:
+		}
+	}
+	d.dropped.Add(1)
+	logger.PrintLog(chatID, "dispatcher queue is full, dropping update", fmt.Errorf("backlog of worker %d is full", shard))
+	return false
+}
+
+// SubmitWait queues job like Submit but waits for room in a full queue
+// without a timeout, for internal jobs such as timeouts that must not
+// get lost. It returns false only if the dispatcher is stopped. It must not
+// be called from a job, which could wait for its own worker.
+func (d *dispatcher) SubmitWait(chatID int64, job func()) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		d.dropped.Add(1)
+		return false
+	}
+
+	d.queues[d.shard(chatID)] <- job
+	d.enqueued.Add(1)
+	return true
+}
+
+// shard returns the index of the worker owning chatID
+func (d *dispatcher) shard(chatID int64) int64 {
+	shard := chatID % int64(len(d.queues))
+	if shard < 0 {
+		shard = -shard // Group and channel chat IDs are negative
+	}
+	return shard
+}
+
+// Stop stops accepting jobs and waits until the queued ones are done
+func (d *dispatcher) Stop() {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		for _, queue := range d.queues {
+			close(queue)
+		}
+	}
+	d.mu.Unlock()
+	d.wg.Wait()
+}
+
+// Stats returns the current counters
+func (d *dispatcher) Stats() DispatcherStats {
+	var backlog int64
+	for _, queue := range d.queues {
+		backlog += int64(len(queue))
+	}
+	return DispatcherStats{
+		Workers:   len(d.queues),
+		Enqueued:  d.enqueued.Load(),
+		Processed: d.processed.Load(),
+		Dropped:   d.dropped.Load(),
+		Panics:    d.panics.Load(),
+		InFlight:  d.inFlight.Load(),
+		Backlog:   backlog,
+	}
+}
+
+func (d *dispatcher) work(queue <-chan func()) {
+	defer d.wg.Done()
+	for job := range queue {
+		d.run(job)
+	}
+}
+
+// run executes a single job and keeps the worker alive if it panics
+func (d *dispatcher) run(job func()) {
+	d.inFlight.Add(1)
+	defer func() {
+		if r := recover(); r != nil {
+			d.panics.Add(1)
+			logger.PrintLog(0, "recovered from panic in update handler", fmt.Errorf("%v", r))
+		}
+		d.inFlight.Add(-1)
+		d.processed.Add(1)
+	}()
+	job()
+}
+
+// activeDispatcher is the dispatcher reported under /debug/vars
+var activeDispatcher atomic.Pointer[dispatcher]
+
+func init() {
+	expvar.Publish("bot_dispatcher", expvar.Func(func() any {
+		if d := activeDispatcher.Load(); d != nil {
+			return d.Stats()
+		}
+		return nil
+	}))
+}
+
+// serveMetrics exposes the expvar metrics (including the dispatcher stats) on
+// addr. The returned server is shut down with the bot.
+func serveMetrics(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	server := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.PrintLog(0, "metrics server stopped", err)
+		}
+	}()
+	return server
+}
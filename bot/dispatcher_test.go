@@ -0,0 +1,182 @@
+package bot
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDispatcherKeepsChatOrder(t *testing.T) {
+	d := newDispatcher(4, 100)
+
+	var mu sync.Mutex
+	got := make(map[int64][]int)
+	for i := 0; i < 50; i++ {
+		for _, chatID := range []int64{1, 2, -3} {
+			i, chatID := i, chatID
+			d.Submit(chatID, func() {
+				mu.Lock()
+				got[chatID] = append(got[chatID], i)
+				mu.Unlock()
+			}, 0)
+		}
+	}
+	d.Stop()
+
+	for chatID, seq := range got {
+		if len(seq) != 50 {
+			t.Fatalf("chat %d: got %d jobs, want 50", chatID, len(seq))
+		}
+		for i, v := range seq {
+			if v != i {
+				t.Fatalf("chat %d: job %d ran at position %d", chatID, v, i)
+			}
+		}
+	}
+	if stats := d.Stats(); stats.Processed != 150 || stats.Dropped != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestDispatcherDropsWhenFull(t *testing.T) {
+	d := newDispatcher(1, 1)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	d.Submit(1, func() { close(started); <-release }, 0)
+	<-started
+
+	if !d.Submit(1, func() {}, 0) {
+		t.Fatal("expected the job to be queued")
+	}
+	if d.Submit(1, func() {}, 0) {
+		t.Fatal("expected the job to be dropped")
+	}
+	if stats := d.Stats(); stats.Dropped != 1 || stats.Backlog != 1 || stats.InFlight != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	close(release)
+	d.Stop()
+}
+
+func TestDispatcherSubmitTimeout(t *testing.T) {
+	d := newDispatcher(1, 1)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	d.Submit(1, func() { close(started); <-release }, 0)
+	<-started
+	d.Submit(1, func() {}, 0)
+
+	if d.Submit(1, func() {}, 20*time.Millisecond) {
+		t.Fatal("expected the job to be dropped after the timeout")
+	}
+
+	queued := make(chan bool)
+	go func() { queued <- d.Submit(1, func() {}, time.Minute) }()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	if !<-queued {
+		t.Fatal("expected the job to be queued once there is room")
+	}
+	d.Stop()
+	if stats := d.Stats(); stats.Dropped != 1 || stats.Processed != 3 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestDispatcherRecoversPanics(t *testing.T) {
+	d := newDispatcher(1, 10)
+
+	done := make(chan struct{})
+	d.Submit(1, func() { panic("boom") }, 0)
+	d.Submit(1, func() { close(done) }, 0)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("worker did not survive the panic")
+	}
+	d.Stop()
+	if stats := d.Stats(); stats.Panics != 1 {
+		t.Errorf("got %d panics, want 1", stats.Panics)
+	}
+}
+
+func TestDispatcherRejectsAfterStop(t *testing.T) {
+	d := newDispatcher(2, 10)
+	d.Stop()
+	d.Stop()
+
+	if d.Submit(1, func() { t.Error("job ran after stop") }, 0) {
+		t.Fatal("expected the job to be rejected")
+	}
+}
+
+func TestDispatcherSubmitWaitsWhenFull(t *testing.T) {
+	d := newDispatcher(1, 1)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	d.Submit(1, func() { close(started); <-release }, 0)
+	<-started
+	d.Submit(1, func() {}, 0)
+
+	ran := make(chan struct{})
+	queued := make(chan bool)
+	go func() { queued <- d.SubmitWait(1, func() { close(ran) }) }()
+	select {
+	case <-queued:
+		t.Fatal("expected SubmitWait to wait for room in the queue")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if !<-queued {
+		t.Fatal("expected the job to be queued")
+	}
+	<-ran
+	d.Stop()
+	if stats := d.Stats(); stats.Dropped != 0 || stats.Processed != 3 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if d.SubmitWait(1, func() { t.Error("job ran after stop") }) {
+		t.Error("expected the job to be rejected after stop")
+	}
+}
+
+func TestMetricsServerShutdown(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	server := serveMetrics(addr)
+	url := "http://" + addr + "/debug/vars"
+	deadline := time.Now().Add(time.Second)
+	for {
+		resp, err := http.Get(url)
+		if err == nil {
+			resp.Body.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("metrics server did not start: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := server.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := http.Get(url); err == nil {
+		resp.Body.Close()
+		t.Error("expected the metrics server to be stopped")
+	}
+}
@@ -0,0 +1,94 @@
+package bot
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go-tg-support-ticket/form"
+	"go-tg-support-ticket/session"
+	"go-tg-support-ticket/webhook"
+)
+
+// publish sends a lifecycle event of the session to the webhook endpoints
+// subscribed to it
+func (b *Bot) publish(s *session.Session, eventType string, data map[string]interface{}) {
+	if webhook.Workers != nil {
+		webhook.Workers.Publish(webhook.NewSessionEvent(s, eventType, data))
+	}
+}
+
+// record stores value as the answer to the current input, moves on and
+// publishes field.answered
+func (b *Bot) record(s *session.Session, value string) {
+	field, group, ok := currentInput(s)
+	s.Record(value)
+	if ok {
+		b.publishAnswer(s, field, group, value)
+	}
+}
+
+// skip records that the user skipped the current input, moves on and
+// publishes field.skipped
+func (b *Bot) skip(s *session.Session) {
+	field, group, ok := currentInput(s)
+	s.Skip()
+	if ok {
+		b.publish(s, webhook.FieldSkipped, answerData(field, group))
+	}
+}
+
+// currentInput returns the input the user answers and the name of the group
+// it is a sub-field of, empty outside of groups
+func currentInput(s *session.Session) (form.Field, string, bool) {
+	field, ok := s.CurrentInput()
+	if current, _ := s.CurrentField(); current.Type == form.GroupType {
+		return field, current.Name, ok
+	}
+	return field, "", ok
+}
+
+// publishAnswer publishes the answer to the field, which is a sub-field of
+// the named group if group is not empty
+func (b *Bot) publishAnswer(s *session.Session, field form.Field, group, value string) {
+	data := answerData(field, group)
+	data["value"] = webhook.FieldValue(s, field, value)
+	b.publish(s, webhook.FieldAnswered, data)
+}
+
+// answerData is the data of the field events
+func answerData(field form.Field, group string) map[string]interface{} {
+	data := map[string]interface{}{"field": field.Name}
+	if group != "" {
+		data["group"] = group
+	}
+	return data
+}
+
+// progress describes how far the user got, for the events of a session that
+// ends without a submission: the field the user stopped at, nil at the
+// review, and the number of answers given, not counting skipped fields
+func progress(s *session.Session) map[string]interface{} {
+	var answered int
+	for _, field := range s.Fields() {
+		if !field.IsNull() {
+			answered++
+		}
+	}
+	var current interface{}
+	if field, ok := s.CurrentField(); ok {
+		current = field.Name
+	}
+	return map[string]interface{}{"field": current, "answered": answered}
+}
+
+// sessionUser returns the Telegram user for the session, nil if unknown
+func sessionUser(u *tgbotapi.User) *session.User {
+	if u == nil {
+		return nil
+	}
+	return &session.User{
+		ID:           u.ID,
+		Username:     u.UserName,
+		FirstName:    u.FirstName,
+		LastName:     u.LastName,
+		LanguageCode: u.LanguageCode,
+	}
+}
@@ -0,0 +1,173 @@
+package bot
+
+import (
+	"context"
+	"go-tg-support-ticket/form"
+	"go-tg-support-ticket/session"
+	"go-tg-support-ticket/webhook"
+	"sync"
+	"testing"
+	"time"
+)
+
+// eventRecorder keeps the published webhook events in memory
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []webhook.Event
+}
+
+func (r *eventRecorder) Enqueue(s *session.Session) {
+	r.Publish(webhook.NewEvent(s))
+}
+
+func (r *eventRecorder) Publish(e webhook.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+func (r *eventRecorder) Shutdown(context.Context) error {
+	return nil
+}
+
+// waitFor returns the events once one of the given type was published
+func (r *eventRecorder) waitFor(t *testing.T, eventType string) []webhook.Event {
+	t.Helper()
+	deadline := time.Now().Add(flowTimeout)
+	for {
+		r.mu.Lock()
+		events := append([]webhook.Event(nil), r.events...)
+		r.mu.Unlock()
+		for _, e := range events {
+			if e.Type == eventType {
+				return events
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("no %s event, got %v", eventType, events)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// recordEvents makes the bot publish to a recorder for the rest of the test
+func recordEvents(t *testing.T) *eventRecorder {
+	recorder := &eventRecorder{}
+	prev := webhook.Workers
+	webhook.Workers = recorder
+	t.Cleanup(func() { webhook.Workers = prev })
+	return recorder
+}
+
+func TestLifecycleEvents(t *testing.T) {
+	events := recordEvents(t)
+	srv := startFlowBot(t)
+	const chat = 11
+
+	steps := []struct {
+		name string
+		send func() error
+		want string
+	}{
+		{"Start", func() error { srv.SendText(chat, "/start"); return nil }, "What is your name?"},
+		{"Answer", func() error { srv.SendText(chat, "Ada"); return nil }, "Pick a category"},
+		{"Press option", func() error { return srv.Click(chat, "Billing") }, "Upload your logs"},
+		{"Skip", func() error { return srv.Click(chat, "skip") }, "Review Your Inputs"},
+		{"Modify", func() error { return srv.Click(chat, "modify_name") }, "Please enter a new value"},
+		{"New value", func() error { srv.SendText(chat, "Grace"); return nil }, "Grace"},
+		{"Submit", func() error { return srv.Click(chat, "submit") }, "submitted successfully"},
+	}
+	for _, step := range steps {
+		if err := step.send(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if _, err := srv.WaitForText(chat, step.want, flowTimeout); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+	}
+	srv.SendText(chat, "/start")
+	srv.SendText(chat, "/end")
+
+	got := events.waitFor(t, webhook.SessionEnded)
+	want := []struct {
+		eventType string
+		field     interface{}
+		value     interface{}
+	}{
+		{webhook.SessionStarted, nil, nil},
+		{webhook.FieldAnswered, "name", "Ada"},
+		{webhook.FieldAnswered, "category", "Billing"},
+		{webhook.FieldSkipped, "logs", nil},
+		{webhook.ReviewModified, "name", "Grace"},
+		{webhook.FormSubmitted, nil, nil},
+		{webhook.SessionStarted, nil, nil},
+		{webhook.SessionEnded, "name", nil},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d events %v, want %d", len(got), got, len(want))
+	}
+	for i, w := range want {
+		e := got[i]
+		data, _ := e.Data.(map[string]interface{})
+		if e.Type != w.eventType || data["field"] != w.field || data["value"] != w.value {
+			t.Errorf("event %d: got %s %v, want %s of %v with %v", i, e.Type, data, w.eventType, w.field, w.value)
+		}
+		if e.SchemaVersion != webhook.SchemaVersion || e.Form != "support" || e.ChatID != chat || e.Timestamp.IsZero() {
+			t.Errorf("event %d: incomplete envelope %+v", i, e)
+		}
+		if e.User == nil || e.User.ID != chat || e.User.Username != "user11" {
+			t.Errorf("event %d: got user %+v, want the sender", i, e.User)
+		}
+		// Events of a session share its submission ID; a restart gets a new one
+		first := got[0]
+		if i >= 6 {
+			first = got[6]
+		}
+		if e.SubmissionID == "" || e.SubmissionID != first.SubmissionID {
+			t.Errorf("event %d: got submission ID %q, want %q", i, e.SubmissionID, first.SubmissionID)
+		}
+	}
+	if got[0].SubmissionID == got[6].SubmissionID {
+		t.Errorf("got submission ID %q for two sessions", got[0].SubmissionID)
+	}
+	if data, _ := got[5].Data.(map[string]interface{}); data["name"] != "Grace" {
+		t.Errorf("got submission %v, want the modified name", data)
+	}
+	if data, _ := got[7].Data.(map[string]interface{}); data["reason"] != webhook.EndedByCommand || data["answered"] != 0 {
+		t.Errorf("got session.ended data %v", data)
+	}
+}
+
+func TestTimedOutEvent(t *testing.T) {
+	events := recordEvents(t)
+	srv := startFlowBot(t, func(b *Bot) { b.sessionTimeout = 100 * time.Millisecond })
+	const chat = 12
+
+	srv.SendText(chat, "/start")
+	srv.SendText(chat, "Ada")
+	if _, err := srv.WaitForText(chat, "Pick a category", flowTimeout); err != nil {
+		t.Fatal(err)
+	}
+
+	got := events.waitFor(t, webhook.SessionTimedOut)
+	data, _ := got[len(got)-1].Data.(map[string]interface{})
+	if data["field"] != "category" || data["answered"] != 1 {
+		t.Errorf("got session.timed_out data %v, want the progress", data)
+	}
+}
+
+func TestProgressIgnoresSkippedFields(t *testing.T) {
+	f := &form.Form{Fields: []form.Field{
+		{Name: "name", Type: "text"},
+		{Name: "phone", Type: "text", Skippable: true},
+		{Name: "email", Type: "email"},
+	}}
+	s := session.New(1, f)
+	s.Record("Ada")
+	s.Skip()
+
+	data := progress(s)
+	if data["field"] != "email" || data["answered"] != 1 {
+		t.Errorf("got %v, want email with 1 answer", data)
+	}
+}
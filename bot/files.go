@@ -0,0 +1,217 @@
+package bot
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/google/uuid"
+	"go-tg-support-ticket/form"
+	"go-tg-support-ticket/session"
+	"go-tg-support-ticket/storage"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxDownloadSize = 20 << 20 // Largest file the Bot API lets bots download
+	downloadTimeout = 60 * time.Second
+)
+
+var downloadClient = &http.Client{Timeout: downloadTimeout}
+
+// errFileTooLarge is returned when a download exceeds the size limit
+var errFileTooLarge = errors.New("file too large")
+
+// messageFile returns the file attached to the message: a document, a video
+// or the largest size of a photo
+func messageFile(m *tgbotapi.Message) (form.File, bool) {
+	switch {
+	case m.Document != nil:
+		return form.File{
+			FileID:       m.Document.FileID,
+			FileUniqueID: m.Document.FileUniqueID,
+			Name:         m.Document.FileName,
+			Size:         int64(m.Document.FileSize),
+			MimeType:     m.Document.MimeType,
+		}, true
+	case len(m.Photo) > 0:
+		// Telegram sends several resolutions of a photo, the last one is the largest
+		photo := m.Photo[len(m.Photo)-1]
+		return form.File{
+			FileID:       photo.FileID,
+			FileUniqueID: photo.FileUniqueID,
+			Size:         int64(photo.FileSize),
+			MimeType:     "image/jpeg",
+		}, true
+	case m.Video != nil:
+		return form.File{
+			FileID:       m.Video.FileID,
+			FileUniqueID: m.Video.FileUniqueID,
+			Name:         m.Video.FileName,
+			Size:         int64(m.Video.FileSize),
+			MimeType:     m.Video.MimeType,
+		}, true
+	}
+	return form.File{}, false
+}
+
+// uploadLimit is the largest file accepted for the field: its
+// max_file_size, capped by what bots can download if files are stored
+func uploadLimit(field form.Field) form.ByteSize {
+	limit := field.Validation.MaxFileSize
+	if storage.Files != nil && (limit == 0 || limit > maxDownloadSize) {
+		limit = maxDownloadSize
+	}
+	return limit
+}
+
+// checkFile checks an upload against the constraints of the field, using
+// the metadata Telegram sends along, so nothing is downloaded for rejected files.
+func checkFile(msgs form.Message, field form.Field, file form.File, limit form.ByteSize) (string, error) {
+	if limit > 0 && form.ByteSize(file.Size) > limit {
+		userMsg := fmt.Sprintf(msgs.FileTooLarge, field.Label, limit)
+		logMsg := fmt.Errorf("validation error for %s: file of %d bytes exceeds the limit of %d bytes", field.Label, file.Size, limit)
+		return userMsg, logMsg
+	}
+
+	exts := fileExtensions(file)
+	if !field.AllowsExtension(exts...) {
+		userMsg := fmt.Sprintf(msgs.FileExtensionNotAllowed, field.Label, strings.Join(field.Validation.AllowedExtensions, ", "))
+		logMsg := fmt.Errorf("validation error for %s: extension of %q is not allowed", field.Label, file.Name)
+		return userMsg, logMsg
+	}
+
+	mimeType := file.MimeType
+	if mimeType == "" && len(exts) > 0 {
+		mimeType = mime.TypeByExtension(exts[0])
+	}
+	if !field.AllowsMimeType(mimeType) {
+		userMsg := fmt.Sprintf(msgs.FileTypeNotAllowed, field.Label, strings.Join(field.Validation.AllowedMimeTypes, ", "))
+		logMsg := fmt.Errorf("validation error for %s: MIME type %q is not allowed", field.Label, mimeType)
+		return userMsg, logMsg
+	}
+
+	return "", nil
+}
+
+// fileExtensions returns the extension of the file name, or the extensions
+// of its MIME type for photos and other files without a name
+func fileExtensions(file form.File) []string {
+	if ext := path.Ext(file.Name); ext != "" {
+		return []string{strings.ToLower(ext)}
+	}
+	exts, _ := mime.ExtensionsByType(file.MimeType)
+	return exts
+}
+
+// storeFile copies the file to the configured storage and fills in its
+// size, MIME type, checksum and reference. Without a storage only the
+// Telegram file_id is kept.
+func (b *Bot) storeFile(s *session.Session, field form.Field, file form.File) (form.File, error) {
+	if storage.Files == nil {
+		return file, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), downloadTimeout)
+	defer cancel()
+
+	data, err := b.downloadFile(ctx, file.FileID, int64(uploadLimit(field)))
+	if err != nil {
+		return file, err
+	}
+
+	sum := sha256.Sum256(data)
+	file.SHA256 = hex.EncodeToString(sum[:])
+	file.Size = int64(len(data))
+	if file.MimeType == "" {
+		file.MimeType = http.DetectContentType(data)
+	}
+
+	file.Ref, err = storage.Files.Put(ctx, fileKey(s, file), data, file.MimeType)
+	if err != nil {
+		return file, fmt.Errorf("failed to store file %s: %w", file.FileID, err)
+	}
+	return file, nil
+}
+
+// downloadFile fetches the file from the Bot API, failing with
+// errFileTooLarge past limit bytes. The download URL contains the bot
+// token, so it is kept out of the returned errors.
+func (b *Bot) downloadFile(ctx context.Context, fileID string, limit int64) ([]byte, error) {
+	link, err := b.api.GetFileDirectURL(fileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file %s: %w", fileID, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file %s: %w", fileID, redactURL(err))
+	}
+	resp, err := downloadClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file %s: %w", fileID, redactURL(err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download file %s: status %d", fileID, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file %s: %w", fileID, redactURL(err))
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("file %s is larger than %d bytes: %w", fileID, limit, errFileTooLarge)
+	}
+	return data, nil
+}
+
+// redactURL drops the URL, and with it the bot token, from request errors
+func redactURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
+
+// fileKey names the stored copy <form slug>/<chat id>/<uuid><extension>
+func fileKey(s *session.Session, file form.File) string {
+	ext := ""
+	if exts := fileExtensions(file); len(exts) > 0 {
+		ext = exts[0]
+		// Prefer the extension named like the subtype, e.g. ".jpeg" over ".jfif"
+		for _, e := range exts {
+			if _, subtype, _ := strings.Cut(file.MimeType, "/"); e == "."+subtype {
+				ext = e
+			}
+		}
+	}
+	return path.Join(s.Form.Slug, strconv.FormatInt(s.ChatID, 10), uuid.NewString()+strings.ToLower(ext))
+}
+
+// formatFiles renders the uploads of a file field for the review
+func formatFiles(field form.Field) string {
+	files, err := field.Files()
+	if err != nil || files == nil {
+		return field.UserValue
+	}
+	names := make([]string, 0, len(files))
+	for i, file := range files {
+		name := file.Name
+		if name == "" {
+			name = fmt.Sprintf("file %d", i+1)
+		}
+		names = append(names, name)
+	}
+	return fmt.Sprintf("📎 %d: %s", len(files), strings.Join(names, ", "))
+}
@@ -0,0 +1,77 @@
+package bot
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go-tg-support-ticket/form"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMessageFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  *tgbotapi.Message
+		wantID   string
+		wantMime string
+		wantOK   bool
+	}{
+		{"Document", &tgbotapi.Message{Document: &tgbotapi.Document{FileID: "doc", FileName: "log.txt", MimeType: "text/plain"}}, "doc", "text/plain", true},
+		{"Largest photo", &tgbotapi.Message{Photo: []tgbotapi.PhotoSize{{FileID: "small"}, {FileID: "large"}}}, "large", "image/jpeg", true},
+		{"Video", &tgbotapi.Message{Video: &tgbotapi.Video{FileID: "video", MimeType: "video/mp4"}}, "video", "video/mp4", true},
+		{"Text", &tgbotapi.Message{Text: "hello"}, "", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file, ok := messageFile(test.message)
+			if ok != test.wantOK {
+				t.Fatalf("expected ok %v, got %v", test.wantOK, ok)
+			}
+			if file.FileID != test.wantID || file.MimeType != test.wantMime {
+				t.Errorf("expected %s (%s), got %s (%s)", test.wantID, test.wantMime, file.FileID, file.MimeType)
+			}
+		})
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	_, err := http.Get("http://127.0.0.1:0/file/bot123:SECRET/documents/file_1.txt")
+	if err == nil {
+		t.Fatal("expected a request error")
+	}
+	if strings.Contains(redactURL(err).Error(), "SECRET") {
+		t.Errorf("expected the token to be removed, got %v", redactURL(err))
+	}
+}
+
+func TestCheckFile(t *testing.T) {
+	field := form.Field{Name: "shot", Label: "Screenshot", Type: form.FileType, Validation: form.Validation{
+		MaxFileSize:       10 << 20,
+		AllowedMimeTypes:  []string{"image/*", "video/mp4"},
+		AllowedExtensions: []string{".png", ".jpg", ".jpeg", ".mp4"},
+	}}
+
+	tests := []struct {
+		name        string
+		file        form.File
+		expectError bool
+	}{
+		{"Photo", form.File{Size: 200 << 10, MimeType: "image/jpeg"}, false},
+		{"Screen recording", form.File{Name: "rec.mp4", Size: 1536 << 20, MimeType: "video/mp4"}, true},
+		{"Executable", form.File{Name: "setup.exe", Size: 1 << 20, MimeType: "application/x-msdownload"}, true},
+		{"Renamed executable", form.File{Name: "setup.png", Size: 1 << 20, MimeType: "application/x-msdownload"}, true},
+		{"Missing MIME type", form.File{Name: "shot.png", Size: 1 << 20}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			userMsg, err := checkFile(defaultForm.Messages, field, test.file, field.Validation.MaxFileSize)
+			if test.expectError && (err == nil || userMsg == "") {
+				t.Errorf("expected an error with a user message")
+			} else if !test.expectError && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package bot
+
+import (
+	"context"
+	"go-tg-support-ticket/bot/bottest"
+	"go-tg-support-ticket/form"
+	"go-tg-support-ticket/storage"
+	"go-tg-support-ticket/webhook"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const flowTimeout = 2 * time.Second
+
+const flowForm = `{
+  "form_name": "Support",
+  "slug": "support",
+  "table_name": "tickets",
+  "review_enabled": true,
+  "db": "sqlite",
+  "fields": [
+    {"name": "name", "label": "Name", "type": "text", "required": true, "description": "What is your name?"},
+    {"name": "category", "label": "Category", "type": "select", "options": ["Billing", "Technical"], "description": "Pick a category",
+      "buttons": [{"text": "Billing", "data": "Billing"}, {"text": "Technical", "data": "Technical"}]},
+    {"name": "logs", "label": "Logs", "type": "file", "skippable": true, "description": "Upload your logs",
+      "validation": {"allowed_extensions": [".txt", ".log"]}}
+  ]
+}`
+
+// startFlowBot serves the test form through a fake Telegram server. The
+// options can change the bot before it starts.
+func startFlowBot(t *testing.T, options ...func(*Bot)) *bottest.Server {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "support.json")
+	if err := os.WriteFile(path, []byte(flowForm), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	forms, err := form.LoadForms([]string{path})
+	if err != nil {
+		t.Fatalf("failed to load form: %v", err)
+	}
+
+	srv := bottest.NewServer()
+	t.Cleanup(srv.Close)
+	api, err := srv.API()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewBotWithAPI(&Config{Token: bottest.Token}, forms, api)
+	if err != nil {
+		t.Fatalf("failed to create bot: %v", err)
+	}
+	for _, option := range options {
+		option(b)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- b.Start(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("bot stopped with error: %v", err)
+		}
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), flowTimeout)
+		defer cancel()
+		if err := b.Shutdown(shutdownCtx); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+	})
+	return srv
+}
+
+func TestConversationFlow(t *testing.T) {
+	prevFiles, prevWorkers := storage.Files, webhook.Workers
+	t.Cleanup(func() { storage.Files, webhook.Workers = prevFiles, prevWorkers })
+	if err := storage.NewStorage(&storage.Config{Type: "local", Local: storage.LocalConfig{Dir: t.TempDir()}}); err != nil {
+		t.Fatal(err)
+	}
+	webhook.Workers = nil // The submission is not sent anywhere
+
+	srv := startFlowBot(t)
+	const chat = 42
+
+	steps := []struct {
+		name string
+		send func() error
+		want string
+	}{
+		{"Start", func() error { srv.SendText(chat, "/start"); return nil }, "What is your name?"},
+		{"Answer text", func() error { srv.SendText(chat, "Ada"); return nil }, "Pick a category"},
+		{"Press option", func() error { return srv.Click(chat, "Technical") }, "Upload your logs"},
+		{"Rejected file", func() error {
+			srv.SendDocument(chat, "setup.exe", "application/x-msdownload", []byte("MZ"))
+			return nil
+		}, "must end in one of"},
+		{"Accepted file", func() error { srv.SendDocument(chat, "error.log", "text/plain", []byte("panic")); return nil }, "uploaded successfully"},
+		{"Finish uploading", func() error { return srv.Click(chat, "finish_uploading") }, "Review Your Inputs"},
+		{"Submit", func() error { return srv.Click(chat, "submit") }, "submitted successfully"},
+	}
+
+	for _, step := range steps {
+		if err := step.send(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		m, err := srv.WaitForText(chat, step.want, flowTimeout)
+		if err != nil {
+			for _, m := range srv.Messages(chat) {
+				t.Logf("%s %q %v", m.Method, m.Text, m.Buttons)
+			}
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if step.name == "Finish uploading" {
+			if !strings.Contains(m.Text, "Ada") || !strings.Contains(m.Text, "error.log") {
+				t.Errorf("expected the answers in the review, got %q", m.Text)
+			}
+		}
+	}
+}
+
+func TestAlbumIsAnsweredOnce(t *testing.T) {
+	var b *Bot
+	srv := startFlowBot(t, func(bot *Bot) {
+		b = bot
+		b.albumDebounce = 50 * time.Millisecond
+	})
+	const chat = 7
+
+	srv.SendText(chat, "/start")
+	srv.SendText(chat, "Grace")
+	if _, err := srv.WaitForText(chat, "Pick a category", flowTimeout); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.Click(chat, "Billing"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.WaitForText(chat, "Upload your logs", flowTimeout); err != nil {
+		t.Fatal(err)
+	}
+
+	// Photos have no name, so they are rejected by allowed_extensions
+	for i := 0; i < 3; i++ {
+		srv.SendPhoto(chat, []byte("jpeg"), "album-1")
+	}
+	if _, err := srv.WaitForText(chat, "must end in one of", flowTimeout); err != nil {
+		t.Fatal(err)
+	}
+
+	// Six updates and one answer of the album; a split album runs more jobs
+	deadline := time.Now().Add(flowTimeout)
+	for {
+		_, collecting := b.albums.Load(chat)
+		stats := b.dispatcher.Stats()
+		if !collecting && stats.Processed >= 7 && stats.InFlight == 0 && stats.Backlog == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("album was not answered, dispatcher stats %+v", stats)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	var rejections int
+	for _, m := range srv.Messages(chat) {
+		if strings.Contains(m.Text, "must end in one of") {
+			rejections++
+		}
+	}
+	if rejections != 1 {
+		t.Errorf("expected one answer for the album, got %d", rejections)
+	}
+}
@@ -0,0 +1,64 @@
+package bot
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go-tg-support-ticket/logger"
+)
+
+// formMenuPrefix marks callback data of the form menu buttons. The colon
+// cannot clash with field buttons, whose data is limited to [a-zA-Z0-9_-].
+const formMenuPrefix = "form:"
+
+// defaultFormMenu is shown above the form menu unless bot.form_menu is set
+const defaultFormMenu = "📋 Which form would you like to fill in?"
+
+// handleStart starts the form named by the /start argument, e.g. from a
+// t.me/<bot>?start=<slug> deep link. Without a known slug, the only form is
+// started, or the user picks one from the menu.
+func (b *Bot) handleStart(chatID int64, user *tgbotapi.User, slug string) {
+	if slug != "" {
+		if f, ok := b.formsBySlug[slug]; ok {
+			b.startSession(chatID, user, f)
+			return
+		}
+		logger.PrintLog(chatID, "unknown form requested: "+slug, nil)
+	}
+
+	if len(b.forms) == 1 {
+		b.startSession(chatID, user, b.forms[0])
+		return
+	}
+	b.sendFormMenu(chatID)
+}
+
+// handleFormChoice starts the form picked from the menu
+func (b *Bot) handleFormChoice(chatID int64, user *tgbotapi.User, slug string) {
+	f, ok := b.formsBySlug[slug]
+	if !ok {
+		logger.PrintLog(chatID, "unknown form picked from menu: "+slug, nil)
+		b.sendFormMenu(chatID)
+		return
+	}
+	b.startSession(chatID, user, f)
+}
+
+// sendFormMenu lists every form as an inline button
+func (b *Bot) sendFormMenu(chatID int64) {
+	text := b.cfg.FormMenu
+	if text == "" {
+		text = defaultFormMenu
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, f := range b.forms {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(f.FormName, formMenuPrefix+f.Slug),
+		))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	if _, err := b.api.Send(msg); err != nil {
+		logger.PrintLog(chatID, "failed to send form menu", err)
+	}
+}
@@ -0,0 +1,123 @@
+package bot
+
+import (
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go-tg-support-ticket/form"
+	"go-tg-support-ticket/logger"
+	"go-tg-support-ticket/session"
+	"strings"
+)
+
+// sendGroupPrompt asks the user to add an item to the group or to finish it.
+// The group is finished automatically once max_items is reached.
+func (b *Bot) sendGroupPrompt(s *session.Session, field form.Field) {
+	chatID := s.ChatID
+	count := len(s.Items)
+
+	if field.Validation.MaxItems > 0 && count >= field.Validation.MaxItems {
+		b.handleFinishGroup(s)
+		return
+	}
+
+	text := field.Description
+	if count > 0 {
+		text = fmt.Sprintf(s.Form.Messages.AddAnother, field.Label)
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf(s.Form.Messages.AddItemButton, field.Label), "add_item"),
+	))
+	if count >= field.Validation.MinItems {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(s.Form.Messages.FinishGroupButton, "finish_group"),
+		))
+	}
+	rows = append(rows, backRow(s)...)
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	if count == 0 {
+		if field.Formatting == "Markdown" {
+			msg.ParseMode = tgbotapi.ModeMarkdown
+		} else if field.Formatting == "HTML" {
+			msg.ParseMode = tgbotapi.ModeHTML
+		}
+	}
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	if _, err := b.api.Send(msg); err != nil {
+		logger.PrintLog(chatID, "failed to send group prompt", err)
+	}
+}
+
+// handleAddItem starts a new item of the current group
+func (b *Bot) handleAddItem(s *session.Session) {
+	field, ok := s.CurrentField()
+	if !ok || field.Type != form.GroupType || s.InItem {
+		return
+	}
+	s.StartItem()
+	b.generateFormStep(s)
+}
+
+// handleFinishGroup completes the current group if enough items were added
+func (b *Bot) handleFinishGroup(s *session.Session) {
+	chatID := s.ChatID
+
+	field, ok := s.CurrentField()
+	if !ok || field.Type != form.GroupType || s.InItem {
+		return
+	}
+
+	if len(s.Items) < field.Validation.MinItems {
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf(s.Form.Messages.RequiredItems, field.Validation.MinItems, field.Label))
+		if _, err := b.api.Send(msg); err != nil {
+			logger.PrintLog(chatID, "failed to send required items message", err)
+		}
+		return
+	}
+
+	if err := s.FinishGroup(); err != nil {
+		logger.PrintLog(chatID, "failed to store group items", err)
+		return
+	}
+	b.publishAnswer(s, field, "", s.Answer(field.Name))
+	b.generateFormStep(s)
+}
+
+// modifyGroup restarts a group from the review screen. It returns false if
+// the named field is not a group.
+func (b *Bot) modifyGroup(s *session.Session, fieldName string) bool {
+	for _, field := range s.Form.Fields {
+		if field.Name == fieldName && field.Type == form.GroupType {
+			s.Reset(fieldName)
+			if s.Resume() {
+				b.generateFormStep(s)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// formatGroupValue renders the items of a group for the review message
+func formatGroupValue(field form.Field) string {
+	items, err := field.GroupItems()
+	if err != nil || len(items) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	for i, item := range items {
+		var parts []string
+		for _, sub := range field.Fields {
+			value := item[sub.Name]
+			if value == "" {
+				value = "Not provided"
+			}
+			parts = append(parts, fmt.Sprintf("%s: %s", sub.Label, value))
+		}
+		sb.WriteString(fmt.Sprintf("\n  %d. %s", i+1, strings.Join(parts, ", ")))
+	}
+	return sb.String()
+}
@@ -0,0 +1,201 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go-tg-support-ticket/form"
+	"go-tg-support-ticket/logger"
+	"go-tg-support-ticket/session"
+	"strconv"
+	"strings"
+)
+
+// multiselectPrefix marks callback data of multiselect keyboards. Options
+// are referenced by index, so long option texts fit into the callback data.
+const multiselectPrefix = "ms:"
+
+const multiselectDone = multiselectPrefix + "done"
+
+// sendMultiselect shows the options of a multiselect field as toggle buttons.
+// The selection starts from the earlier answer if the user came back to it.
+func (b *Bot) sendMultiselect(s *session.Session, field form.Field) {
+	s.Selected = nil
+	if previous, ok := s.Previous(); ok {
+		field.UserValue = previous
+		if selected, err := field.Selections(); err == nil {
+			s.Selected = selected
+		}
+	}
+
+	msg := tgbotapi.NewMessage(s.ChatID, s.Form.Messages.ChooseOptions)
+	msg.ReplyMarkup = multiselectKeyboard(s, field)
+	if _, err := b.api.Send(msg); err != nil {
+		logger.PrintLog(s.ChatID, "failed to send multiselect keyboard", err)
+	}
+}
+
+// multiselectKeyboard renders the options with a ✅ in front of the selected ones
+func multiselectKeyboard(s *session.Session, field form.Field) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, option := range field.Options {
+		text := option
+		if contains(s.Selected, option) {
+			text = "✅ " + option
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(text, multiselectPrefix+strconv.Itoa(i)),
+		))
+	}
+
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(s.Form.Messages.SelectDoneButton, multiselectDone),
+	))
+	if field.Skippable {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(s.Form.Messages.SkipButton, "skip"),
+		))
+	}
+	rows = append(rows, backRow(s)...)
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// handleMultiselect toggles an option by editing the keyboard in place, or
+// records the selection when Done is pressed
+func (b *Bot) handleMultiselect(s *session.Session, query *tgbotapi.CallbackQuery) {
+	chatID := s.ChatID
+	notice := ""
+	defer func() {
+		// Stop the loading indicator, showing the notice if there is one
+		if _, err := b.api.Request(tgbotapi.NewCallback(query.ID, notice)); err != nil {
+			logger.PrintLog(chatID, "failed to answer multiselect callback", err)
+		}
+	}()
+
+	field, ok := s.CurrentInput()
+	if !ok || field.Type != form.MultiselectType {
+		return
+	}
+
+	if query.Data == multiselectDone {
+		if msg, err := validateSelectionCount(s.Form.Messages, field, len(s.Selected)); err != nil {
+			logger.PrintLog(chatID, "user input validation", err)
+			notice = msg
+			return
+		}
+		value, err := encodeSelections(field, s.Selected)
+		if err != nil {
+			logger.PrintLog(chatID, "failed to encode selections", err)
+			return
+		}
+		s.Selected = nil
+		b.record(s, value)
+		b.generateFormStep(s)
+		return
+	}
+
+	i, err := strconv.Atoi(strings.TrimPrefix(query.Data, multiselectPrefix))
+	if err != nil || i < 0 || i >= len(field.Options) {
+		logger.PrintLog(chatID, "invalid multiselect option "+query.Data, err)
+		return
+	}
+	option := field.Options[i]
+
+	if contains(s.Selected, option) {
+		selected := s.Selected[:0]
+		for _, v := range s.Selected {
+			if v != option {
+				selected = append(selected, v)
+			}
+		}
+		s.Selected = selected
+	} else {
+		if max := field.Validation.MaxItems; max > 0 && len(s.Selected) >= max {
+			notice = fmt.Sprintf(s.Form.Messages.TooManySelections, max, field.Label)
+			return
+		}
+		s.Selected = append(s.Selected, option)
+	}
+
+	edit := tgbotapi.NewEditMessageReplyMarkup(chatID, query.Message.MessageID, multiselectKeyboard(s, field))
+	if _, err := b.api.Request(edit); err != nil {
+		logger.PrintLog(chatID, "failed to update multiselect keyboard", err)
+	}
+}
+
+// validateSelectionCount checks the number of selections against min_items and max_items
+func validateSelectionCount(msgs form.Message, field form.Field, count int) (string, error) {
+	min := field.Validation.MinItems
+	if min == 0 && field.Required {
+		min = 1
+	}
+	if count < min {
+		userMsg := fmt.Sprintf(msgs.RequiredSelections, min, field.Label)
+		logMsg := fmt.Errorf("validation error for %s: %d options selected, at least %d required", field.Label, count, min)
+		return userMsg, logMsg
+	}
+	if max := field.Validation.MaxItems; max > 0 && count > max {
+		userMsg := fmt.Sprintf(msgs.TooManySelections, max, field.Label)
+		logMsg := fmt.Errorf("validation error for %s: %d options selected, at most %d allowed", field.Label, count, max)
+		return userMsg, logMsg
+	}
+	return "", nil
+}
+
+// parseSelections reads a typed, comma separated list of options. Options
+// are matched case-insensitively; unknown ones are returned separately.
+func parseSelections(field form.Field, text string) (selected, unknown []string) {
+	for _, part := range strings.Split(text, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		found := false
+		for _, option := range field.Options {
+			if strings.EqualFold(part, option) {
+				if !contains(selected, option) {
+					selected = append(selected, option)
+				}
+				found = true
+				break
+			}
+		}
+		if !found {
+			unknown = append(unknown, part)
+		}
+	}
+	return selected, unknown
+}
+
+// encodeSelections stores the selection as a JSON array in option order
+func encodeSelections(field form.Field, selected []string) (string, error) {
+	ordered := make([]string, 0, len(selected))
+	for _, option := range field.Options {
+		if contains(selected, option) {
+			ordered = append(ordered, option)
+		}
+	}
+	data, err := json.Marshal(ordered)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// formatSelections renders the value of a multiselect field for the review
+func formatSelections(field form.Field) string {
+	selected, err := field.Selections()
+	if err != nil || selected == nil {
+		return field.UserValue
+	}
+	return strings.Join(selected, ", ")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
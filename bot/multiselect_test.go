@@ -0,0 +1,33 @@
+package bot
+
+import (
+	"go-tg-support-ticket/form"
+	"testing"
+)
+
+func TestEncodeSelections(t *testing.T) {
+	field := form.Field{Name: "tags", Type: form.MultiselectType, Options: []string{"Red", "Green", "Blue"}}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"Option order", "blue, red", `["Red","Blue"]`},
+		{"Duplicates", "Green, green", `["Green"]`},
+		{"Nothing", "", `[]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selected, _ := parseSelections(field, test.input)
+			got, err := encodeSelections(field, selected)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != test.want {
+				t.Errorf("expected %s, got %s", test.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go-tg-support-ticket/form"
+	"go-tg-support-ticket/logger"
+	"go-tg-support-ticket/session"
+	"strings"
+)
+
+// replyKeyboard returns the keyboard with the button that shares the user's
+// phone number or location for field, or nil if the field does not use one
+func replyKeyboard(msgs form.Message, field form.Field) interface{} {
+	var button tgbotapi.KeyboardButton
+	switch field.Type {
+	case form.PhoneType:
+		button = tgbotapi.NewKeyboardButtonContact(msgs.SharePhoneButton)
+	case form.ContactType:
+		button = tgbotapi.NewKeyboardButtonContact(msgs.ShareContactButton)
+	case form.LocationType:
+		button = tgbotapi.NewKeyboardButtonLocation(msgs.ShareLocationButton)
+	default:
+		return nil
+	}
+	return tgbotapi.NewOneTimeReplyKeyboard(tgbotapi.NewKeyboardButtonRow(button))
+}
+
+// removeReplyKeyboard hides the sharing keyboard once its field is answered
+func (b *Bot) removeReplyKeyboard(s *session.Session) {
+	msg := tgbotapi.NewMessage(s.ChatID, s.Form.Messages.InputReceived)
+	msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(false)
+	if _, err := b.api.Send(msg); err != nil {
+		logger.PrintLog(s.ChatID, "failed to remove reply keyboard", err)
+	}
+}
+
+// handleSharedInput handles a shared contact, location or venue
+func (b *Bot) handleSharedInput(s *session.Session, m *tgbotapi.Message) {
+	chatID := s.ChatID
+
+	field, ok := s.CurrentInput()
+	if s.Modifying != "" {
+		field, ok = s.FieldByName(s.Modifying)
+	}
+	if !ok {
+		return
+	}
+
+	value, userMsg, err := sharedValue(s.Form.Messages, field, m)
+	if err != nil {
+		logger.PrintLog(chatID, "user input validation", err)
+		if _, err := b.api.Send(tgbotapi.NewMessage(chatID, userMsg)); err != nil {
+			logger.PrintLog(chatID, "failed to send error message", err)
+		}
+		return
+	}
+
+	b.removeReplyKeyboard(s)
+	if s.Modifying != "" {
+		s.SetAnswer(field.Name, value)
+		b.finishModification(s)
+		return
+	}
+	b.record(s, value)
+	b.generateFormStep(s)
+}
+
+// sharedValue converts a shared contact, location or venue into the value
+// of field. Contacts and locations are stored as JSON documents, phone
+// numbers as plain text.
+func sharedValue(msgs form.Message, field form.Field, m *tgbotapi.Message) (string, string, error) {
+	var value interface{}
+	switch {
+	case field.Type == form.PhoneType && m.Contact != nil:
+		phone := m.Contact.PhoneNumber
+		if !strings.HasPrefix(phone, "+") {
+			phone = "+" + phone // Telegram sends the number without the plus sign
+		}
+		if phone, ok := form.NormalizePhone(phone); ok {
+			return phone, "", nil
+		}
+		userMsg := fmt.Sprintf(msgs.InvalidPhone, field.Label)
+		logMsg := fmt.Errorf("validation error for %s: shared phone number '%s' is invalid", field.Label, m.Contact.PhoneNumber)
+		return "", userMsg, logMsg
+	case field.Type == form.ContactType && m.Contact != nil:
+		value = form.Contact{
+			PhoneNumber: m.Contact.PhoneNumber,
+			FirstName:   m.Contact.FirstName,
+			LastName:    m.Contact.LastName,
+			UserID:      m.Contact.UserID,
+		}
+	case field.Type == form.LocationType && m.Venue != nil:
+		value = form.Location{
+			Latitude:  m.Venue.Location.Latitude,
+			Longitude: m.Venue.Location.Longitude,
+			Title:     m.Venue.Title,
+			Address:   m.Venue.Address,
+		}
+	case field.Type == form.LocationType && m.Location != nil:
+		value = form.Location{Latitude: m.Location.Latitude, Longitude: m.Location.Longitude}
+	default:
+		userMsg := fmt.Sprintf(msgs.UseKeyboardButton, field.Label)
+		logMsg := fmt.Errorf("validation error for %s: shared input does not match field type %s", field.Label, field.Type)
+		return "", userMsg, logMsg
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		userMsg := fmt.Sprintf(msgs.ValidationError, field.Label)
+		return "", userMsg, fmt.Errorf("failed to encode %s of %s: %w", field.Type, field.Label, err)
+	}
+	return string(data), "", nil
+}
+
+// formatSharedValue renders a location or contact for the review
+func formatSharedValue(field form.Field) string {
+	if field.IsNull() {
+		return field.UserValue
+	}
+
+	switch field.Type {
+	case form.LocationType:
+		l, err := field.LocationValue()
+		if err != nil {
+			return field.UserValue
+		}
+		text := fmt.Sprintf("📍 %.5f, %.5f", l.Latitude, l.Longitude)
+		if l.Title != "" {
+			text = fmt.Sprintf("📍 %s, %s (%.5f, %.5f)", l.Title, l.Address, l.Latitude, l.Longitude)
+		}
+		return text
+	case form.ContactType:
+		c, err := field.ContactValue()
+		if err != nil {
+			return field.UserValue
+		}
+		return strings.TrimSpace(fmt.Sprintf("👤 %s %s, %s", c.FirstName, c.LastName, c.PhoneNumber))
+	}
+	return field.UserValue
+}
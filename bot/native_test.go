@@ -0,0 +1,46 @@
+package bot
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go-tg-support-ticket/form"
+	"testing"
+)
+
+func TestSharedValue(t *testing.T) {
+	contact := &tgbotapi.Contact{PhoneNumber: "14155550123", FirstName: "Ada", UserID: 42}
+	location := &tgbotapi.Location{Latitude: 13.75, Longitude: 100.5}
+
+	tests := []struct {
+		name      string
+		fieldType string
+		message   *tgbotapi.Message
+		want      string
+		wantErr   bool
+	}{
+		{"Phone from contact", form.PhoneType, &tgbotapi.Message{Contact: contact}, "+14155550123", false},
+		{"Contact", form.ContactType, &tgbotapi.Message{Contact: contact}, `{"phone_number":"14155550123","first_name":"Ada","user_id":42}`, false},
+		{"Location", form.LocationType, &tgbotapi.Message{Location: location}, `{"latitude":13.75,"longitude":100.5}`, false},
+		{"Venue", form.LocationType, &tgbotapi.Message{Location: location, Venue: &tgbotapi.Venue{Location: *location, Title: "Office", Address: "Main St 1"}}, `{"latitude":13.75,"longitude":100.5,"title":"Office","address":"Main St 1"}`, false},
+		{"Location for a contact field", form.ContactType, &tgbotapi.Message{Location: location}, "", true},
+		{"Contact for a location field", form.LocationType, &tgbotapi.Message{Contact: contact}, "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			field := form.Field{Name: "f", Label: "F", Type: test.fieldType}
+			got, userMsg, err := sharedValue(defaultForm.Messages, field, test.message)
+			if test.wantErr {
+				if err == nil || userMsg == "" {
+					t.Fatalf("expected an error with a user message, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != test.want {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+		})
+	}
+}
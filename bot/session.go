@@ -0,0 +1,66 @@
+package bot
+
+import (
+	"fmt"
+	"go-tg-support-ticket/internal/store"
+	"go-tg-support-ticket/logger"
+	"go-tg-support-ticket/session"
+	"go-tg-support-ticket/webhook"
+	"time"
+)
+
+// newSessionStore creates the session store selected in the config
+func newSessionStore(cfg *Config) (session.Store, error) {
+	switch cfg.SessionStore {
+	case "", "memory":
+		return session.NewMemoryStore(), nil
+	case "file":
+		return session.NewFileStore(cfg.SessionDir)
+	case "database":
+		return store.Sessions, nil
+	default:
+		return nil, fmt.Errorf("unknown session store %q, must be 'memory', 'file' or 'database'", cfg.SessionStore)
+	}
+}
+
+// saveSession writes the current state of the chat session to the session store
+func (b *Bot) saveSession(chatID int64) {
+	s, ok := b.getSession(chatID)
+	if !ok {
+		return
+	}
+	if err := b.sessionStore.Save(s); err != nil {
+		logger.PrintLog(chatID, "failed to save session", err)
+	}
+}
+
+// restoreSessions loads stored sessions back into memory and restarts their
+// inactivity timers from the stored deadlines. Sessions whose deadline has
+// already passed are ended right away.
+func (b *Bot) restoreSessions() error {
+	sessions, err := b.sessionStore.LoadAll()
+	if err != nil {
+		return err
+	}
+
+	for _, s := range sessions {
+		f, ok := b.formsBySlug[s.FormSlug]
+		if !ok {
+			logger.PrintLog(s.ChatID, "dropping stored session of unknown form "+s.FormSlug, nil)
+			if err := b.sessionStore.Delete(s.ChatID); err != nil {
+				logger.PrintLog(s.ChatID, "failed to delete stored session", err)
+			}
+			continue
+		}
+		s.Form = f
+		b.sessions.Store(s.ChatID, s)
+
+		remaining := time.Until(s.Deadline)
+		if remaining <= 0 {
+			b.endSession(s.ChatID, webhook.SessionTimedOut)
+			continue
+		}
+		b.startInactivityTimer(s.ChatID, remaining)
+	}
+	return nil
+}
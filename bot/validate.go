@@ -0,0 +1,287 @@
+package bot
+
+import (
+	"fmt"
+	"go-tg-support-ticket/form"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultForm holds the built-in messages and settings used when no form is at hand.
+var defaultForm = func() *form.Form {
+	var f form.Form
+	f.DefaultMessages()
+	return &f
+}()
+
+// ValidateField validates the user input for a field using the default messages.
+func ValidateField(field form.Field, text string) (string, error) {
+	return validateField(defaultForm, field, text)
+}
+
+// validateField validates the user input for a field of form f based on its validation rules.
+func validateField(f *form.Form, field form.Field, text string) (string, error) {
+	msgs := f.Messages
+	value := text
+
+	// Skip validation if the field is skippable and the value is empty
+	if field.Skippable && value == "" {
+		return "", nil
+	}
+
+	// Check if the field is required and the value is empty
+	if field.Required && value == "" {
+		//userMsg := fmt.Sprintf("Oops! The input for %s is required. Please provide a value.", field.Name)
+		userMsg := fmt.Sprintf(msgs.RequiredInput, field.Name)
+		logMsg := fmt.Errorf("validation error for %s: input is required but was not provided", field.Name)
+		return userMsg, logMsg
+	}
+
+	// Validate based on the field type
+	switch field.Type {
+	case "text":
+		return validateTextField(msgs, field, value)
+	case "number":
+		return validateNumberField(msgs, field, value)
+	case "email":
+		return validateEmailField(msgs, field, value)
+	case "select":
+		return validateSelectField(msgs, field, value)
+	case "file":
+		return validateFileField(msgs, field, value)
+	case form.MultiselectType:
+		return validateMultiselectField(msgs, field, value)
+	case form.PhoneType:
+		return validatePhoneField(msgs, field, value)
+	case form.LocationType, form.ContactType:
+		userMsg := fmt.Sprintf(msgs.UseKeyboardButton, field.Label)
+		logMsg := fmt.Errorf("validation error for %s: %s fields must be shared, not typed", field.Label, field.Type)
+		return userMsg, logMsg
+	case form.DateType, form.TimeType, form.DateTimeType:
+		return validateTemporalField(msgs, field, value, time.Now().In(f.Location()))
+	default:
+		userMsg := fmt.Sprintf("Oops! Unsupport field type")
+		logMsg := fmt.Errorf("unsupported field type: %s", field.Type)
+		return userMsg, logMsg
+	}
+}
+
+// validateTextField validates a text field with both user and log messages.
+func validateTextField(msgs form.Message, field form.Field, value string) (string, error) {
+	// Check min and max length (if specified)
+	if field.Validation.MinLength > 0 && len(value) < field.Validation.MinLength {
+		//userMsg := fmt.Sprintf("Oops! The input for %s is too short. Please provide at least %d characters.", field.Label, field.Validation.MinLength)
+		userMsg := fmt.Sprintf(msgs.InvalidMinLength, field.Label, field.Validation.MinLength)
+		logMsg := fmt.Errorf("validation error for %s: input is too short. Expected at least %d characters, got %d characters", field.Label, field.Validation.MinLength, len(value))
+		return userMsg, logMsg
+	}
+	if field.Validation.MaxLength > 0 && len(value) > field.Validation.MaxLength {
+		//userMsg := fmt.Sprintf("Oops! The input for %s is too long. Please provide no more than %d characters.", field.Label, field.Validation.MaxLength)
+		userMsg := fmt.Sprintf(msgs.InvalidMaxLength, field.Label, field.Validation.MaxLength)
+		logMsg := fmt.Errorf("validation error for %s: input is too long. Expected no more than %d characters, got %d characters", field.Label, field.Validation.MaxLength, len(value))
+		return userMsg, logMsg
+	}
+
+	// Check regex pattern (if specified)
+	if field.Validation.Regex != "" {
+		matched, err := regexp.MatchString(field.Validation.Regex, value)
+		if err != nil {
+			//userMsg := fmt.Sprintf("Oops! Something went wrong while validating your input for %s. Please try again.", field.Label)
+			userMsg := fmt.Sprintf(msgs.ValidationError, field.Label)
+			logMsg := fmt.Errorf("regex error for %s: %v", field.Label, err)
+			return userMsg, logMsg
+		}
+		if !matched {
+			//userMsg := fmt.Sprintf("Oops! The input for %s doesn't match the required format. Please make sure it’s correct.", field.Label)
+			userMsg := fmt.Sprintf(msgs.InvalidFormat, field.Label)
+			logMsg := fmt.Errorf("validation error for %s: input '%s' does not match required regex '%s'", field.Label, value, field.Validation.Regex)
+			return userMsg, logMsg
+		}
+	}
+
+	return "", nil
+}
+
+// validateNumberField validates a number field with both user and log messages.
+func validateNumberField(msgs form.Message, field form.Field, value string) (string, error) {
+	// Convert the value to a number
+	num, err := strconv.Atoi(value)
+	if err != nil {
+		//userMsg := fmt.Sprintf("Oops! The input for %s must be a valid number. Please provide a valid number.", field.Label)
+		userMsg := fmt.Sprintf(msgs.InvalidNumber, field.Label)
+		logMsg := fmt.Errorf("validation error for %s: failed to convert '%s' to a number. Error: %v", field.Label, value, err)
+		return userMsg, logMsg
+	}
+
+	// Check min and max values (if specified)
+	if field.Validation.Min > 0 && num < field.Validation.Min {
+		//userMsg := fmt.Sprintf("Oops! The input for %s must be at least %d. Please provide a valid number.", field.Label, field.Validation.Min)
+		userMsg := fmt.Sprintf(msgs.InvalidMinNumber, field.Label, field.Validation.Min)
+		logMsg := fmt.Errorf("validation error for %s: input %d is less than the minimum %d", field.Label, num, field.Validation.Min)
+		return userMsg, logMsg
+	}
+	if field.Validation.Max > 0 && num > field.Validation.Max {
+		//userMsg := fmt.Sprintf("Oops! The input for %s must be at most %d. Please provide a valid number.", field.Label, field.Validation.Max)
+		userMsg := fmt.Sprintf(msgs.InvalidMaxNumber, field.Label, field.Validation.Max)
+		logMsg := fmt.Errorf("validation error for %s: input %d exceeds the maximum %d", field.Label, num, field.Validation.Max)
+		return userMsg, logMsg
+	}
+
+	return "", nil
+}
+
+// validateEmailField validates an email field with both user and log messages.
+func validateEmailField(msgs form.Message, field form.Field, value string) (string, error) {
+	// Simple email regex for validation
+	emailRegex := `^[a-z0-9]+@[a-z0-9]+\.[a-z]{2,3}$`
+	matched, err := regexp.MatchString(emailRegex, value)
+	if err != nil {
+		//userMsg := fmt.Sprintf("Oops! Something went wrong while validating your email. Please try again.")
+		userMsg := fmt.Sprintf(msgs.InvalidEmail)
+		logMsg := fmt.Errorf("email validation error for %s: regex failed with error: %v", field.Label, err)
+		return userMsg, logMsg
+	}
+	if !matched {
+		//userMsg := fmt.Sprintf("Oops! The input for %s doesn't look like a valid email address. Please check and try again.", field.Label)
+		userMsg := fmt.Sprintf(msgs.InvalidEmail)
+		logMsg := fmt.Errorf("validation error for %s: email '%s' does not match valid format", field.Label, value)
+		return userMsg, logMsg
+	}
+	return "", nil
+}
+
+// validateSelectField validates a select field with both user and log messages.
+func validateSelectField(msgs form.Message, field form.Field, value string) (string, error) {
+	// Check if the value is one of the allowed options
+	for _, option := range field.Options {
+		if value == option {
+			return "", nil
+		}
+	}
+	//userMsg := fmt.Sprintf("Oops! The input for %s must be one of the following options: %s. Please choose one.", field.Label, strings.Join(field.Options, ", "))
+	userMsg := fmt.Sprintf(msgs.ChooseOption, field.Label, strings.Join(field.Options, ", "))
+	logMsg := fmt.Errorf("validation error for %s: invalid option '%s'. Expected one of: %s", field.Label, value, strings.Join(field.Options, ", "))
+	return userMsg, logMsg
+}
+
+// validateFileField validates a file field with both user and log messages.
+func validateFileField(msgs form.Message, field form.Field, value string) (string, error) {
+	// For file fields, we can check if the value is a valid file path or URL
+	if value == "" && field.Required {
+		//userMsg := fmt.Sprintf("Oops! The input for %s is required. Please upload a file.", field.Label)
+		userMsg := fmt.Sprintf(msgs.RequiredFile, field.Label)
+		logMsg := fmt.Errorf("validation error for %s: file is required but was not provided", field.Label)
+		return userMsg, logMsg
+	}
+	if value == "" {
+		return "", nil
+	}
+
+	// The value is the list of uploaded files; typed text is no upload
+	field.UserValue = value
+	files, err := field.Files()
+	if err != nil {
+		userMsg := fmt.Sprintf(msgs.RequiredFile, field.Label)
+		logMsg := fmt.Errorf("validation error for %s: expected uploaded files: %w", field.Label, err)
+		return userMsg, logMsg
+	}
+
+	if field.Validation.MinFiles > 0 && len(files) < field.Validation.MinFiles {
+		userMsg := fmt.Sprintf(msgs.TooFewFiles, field.Validation.MinFiles, field.Label)
+		logMsg := fmt.Errorf("validation error for %s: %d files uploaded, at least %d required", field.Label, len(files), field.Validation.MinFiles)
+		return userMsg, logMsg
+	}
+	if field.Validation.MaxFiles > 0 && len(files) > field.Validation.MaxFiles {
+		userMsg := fmt.Sprintf(msgs.TooManyFiles, field.Validation.MaxFiles, field.Label)
+		logMsg := fmt.Errorf("validation error for %s: %d files uploaded, at most %d allowed", field.Label, len(files), field.Validation.MaxFiles)
+		return userMsg, logMsg
+	}
+	for _, file := range files {
+		if userMsg, err := checkFile(msgs, field, file, field.Validation.MaxFileSize); err != nil {
+			return userMsg, err
+		}
+	}
+	return "", nil
+}
+
+// validateTemporalField validates a date, time or datetime field with both user and log messages.
+func validateTemporalField(msgs form.Message, field form.Field, value string, now time.Time) (string, error) {
+	t, err := field.ParseTemporal(value, now.Location())
+	if err != nil {
+		userMsg := fmt.Sprintf(msgs.InvalidDateTime, field.Label)
+		if field.Type == form.DateType {
+			userMsg = fmt.Sprintf(msgs.InvalidDate, field.Label)
+		} else if field.Type == form.TimeType {
+			userMsg = fmt.Sprintf(msgs.InvalidTime, field.Label)
+		}
+		logMsg := fmt.Errorf("validation error for %s: %w", field.Label, err)
+		return userMsg, logMsg
+	}
+
+	tooEarly, tooLate, err := field.InRange(t, now)
+	if err != nil {
+		userMsg := fmt.Sprintf(msgs.ValidationError, field.Label)
+		logMsg := fmt.Errorf("range error for %s: %w", field.Label, err)
+		return userMsg, logMsg
+	}
+	if tooEarly != "" {
+		userMsg := fmt.Sprintf(msgs.DateTooEarly, field.Label, tooEarly)
+		logMsg := fmt.Errorf("validation error for %s: %s is before %s", field.Label, value, tooEarly)
+		return userMsg, logMsg
+	}
+	if tooLate != "" {
+		userMsg := fmt.Sprintf(msgs.DateTooLate, field.Label, tooLate)
+		logMsg := fmt.Errorf("validation error for %s: %s is after %s", field.Label, value, tooLate)
+		return userMsg, logMsg
+	}
+	return "", nil
+}
+
+// validateMultiselectField validates a typed, comma separated list of options with both user and log messages.
+func validateMultiselectField(msgs form.Message, field form.Field, value string) (string, error) {
+	selected, unknown := parseSelections(field, value)
+	if len(unknown) > 0 {
+		userMsg := fmt.Sprintf(msgs.RequiredSelect, field.Label, strings.Join(field.Options, ", "))
+		logMsg := fmt.Errorf("validation error for %s: invalid options %s. Expected any of: %s", field.Label, strings.Join(unknown, ", "), strings.Join(field.Options, ", "))
+		return userMsg, logMsg
+	}
+	return validateSelectionCount(msgs, field, len(selected))
+}
+
+// validatePhoneField validates a typed phone number with both user and log messages.
+func validatePhoneField(msgs form.Message, field form.Field, value string) (string, error) {
+	if _, ok := form.NormalizePhone(value); !ok {
+		userMsg := fmt.Sprintf(msgs.InvalidPhone, field.Label)
+		logMsg := fmt.Errorf("validation error for %s: '%s' is not a phone number", field.Label, value)
+		return userMsg, logMsg
+	}
+	return "", nil
+}
+
+// normalizeInput converts a validated input into the representation that is
+// stored, e.g. dates into ISO-8601 and phone numbers without separators.
+func normalizeInput(f *form.Form, field form.Field, text string) string {
+	if field.Type == form.PhoneType {
+		if phone, ok := form.NormalizePhone(text); ok {
+			return phone
+		}
+		return text
+	}
+	if field.Type == form.MultiselectType {
+		selected, _ := parseSelections(field, text)
+		if value, err := encodeSelections(field, selected); err == nil {
+			return value
+		}
+		return text
+	}
+	if !field.IsTemporal() {
+		return text
+	}
+	t, err := field.ParseTemporal(text, f.Location())
+	if err != nil {
+		return text
+	}
+	return field.FormatTemporal(t)
+}
@@ -0,0 +1,180 @@
+package bot
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go-tg-support-ticket/logger"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// secretTokenHeader carries the secret_token given to setWebhook on every update
+const secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// maxUpdateSize limits the body of a webhook request; updates are far smaller
+const maxUpdateSize = 1 << 20
+
+// webhookStopTimeout bounds the wait for running webhook handlers on shutdown
+const webhookStopTimeout = 10 * time.Second
+
+// WebhookConfig holds the settings for receiving updates via webhook
+type WebhookConfig struct {
+	URL                string `mapstructure:"url"`                  // Public HTTPS URL Telegram sends updates to
+	ListenAddr         string `mapstructure:"listen_addr"`          // Local address of the HTTP server, e.g. ":8443"
+	SecretToken        string `mapstructure:"secret_token"`         // Checked against the X-Telegram-Bot-Api-Secret-Token header
+	CertFile           string `mapstructure:"cert_file"`            // TLS certificate; leave empty behind a TLS terminating proxy
+	KeyFile            string `mapstructure:"key_file"`             // TLS private key
+	UploadCertificate  bool   `mapstructure:"upload_certificate"`   // Upload cert_file to Telegram (self-signed certificates)
+	MaxConnections     int    `mapstructure:"max_connections"`      // Max simultaneous connections from Telegram (1-100)
+	DropPendingUpdates bool   `mapstructure:"drop_pending_updates"` // Drop updates queued while the bot was down
+}
+
+// validate checks the webhook settings before anything is started
+func (c *WebhookConfig) validate() error {
+	if c.URL == "" {
+		return fmt.Errorf("bot.webhook.url is required in webhook mode")
+	}
+	if _, err := url.Parse(c.URL); err != nil {
+		return fmt.Errorf("invalid bot.webhook.url: %w", err)
+	}
+	if c.ListenAddr == "" {
+		return fmt.Errorf("bot.webhook.listen_addr is required in webhook mode")
+	}
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return fmt.Errorf("bot.webhook.cert_file and bot.webhook.key_file must be set together")
+	}
+	if c.UploadCertificate && c.CertFile == "" {
+		return fmt.Errorf("bot.webhook.upload_certificate requires bot.webhook.cert_file")
+	}
+	return nil
+}
+
+// startWebhook registers the webhook with Telegram and starts the HTTP server
+// that dispatches incoming updates. The returned channel is closed once the
+// server stops.
+func (b *Bot) startWebhook() (<-chan struct{}, error) {
+	cfg := b.cfg.Webhook
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	hookURL, _ := url.Parse(cfg.URL)
+	path := hookURL.Path
+	if path == "" {
+		path = "/"
+	}
+
+	// Bind first, so a busy port fails the start instead of a background goroutine
+	listener, err := net.Listen("tcp", cfg.ListenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", cfg.ListenAddr, err)
+	}
+
+	if err := b.setWebhook(cfg); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to set webhook: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(path, newWebhookHandler(cfg.SecretToken, b.dispatch))
+	b.server = &http.Server{Handler: mux}
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		var err error
+		if cfg.CertFile != "" {
+			err = b.server.ServeTLS(listener, cfg.CertFile, cfg.KeyFile)
+		} else {
+			err = b.server.Serve(listener)
+		}
+		if err != nil && err != http.ErrServerClosed {
+			logger.PrintLog(0, "webhook server stopped", err)
+		}
+	}()
+
+	return stopped, nil
+}
+
+// stopWebhook stops the server and waits for running handlers. Telegram
+// retries the updates that were not accepted.
+func (b *Bot) stopWebhook() {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookStopTimeout)
+	defer cancel()
+	if err := b.server.Shutdown(ctx); err != nil {
+		logger.PrintLog(0, "failed to stop webhook server gracefully", err)
+		b.server.Close()
+	}
+}
+
+// setWebhook calls setWebhook with the secret token, which the
+// WebhookConfig of the telegram library does not support.
+func (b *Bot) setWebhook(cfg WebhookConfig) error {
+	params := make(tgbotapi.Params)
+	params["url"] = cfg.URL
+	params.AddNonEmpty("secret_token", cfg.SecretToken)
+	params.AddNonZero("max_connections", cfg.MaxConnections)
+	params.AddBool("drop_pending_updates", cfg.DropPendingUpdates)
+
+	var resp *tgbotapi.APIResponse
+	var err error
+	if cfg.UploadCertificate {
+		files := []tgbotapi.RequestFile{{Name: "certificate", Data: tgbotapi.FilePath(cfg.CertFile)}}
+		resp, err = b.api.UploadFiles("setWebhook", params, files)
+	} else {
+		resp, err = b.api.MakeRequest("setWebhook", params)
+	}
+	if err != nil {
+		return err
+	}
+	if !resp.Ok {
+		return fmt.Errorf("telegram rejected webhook: %s", resp.Description)
+	}
+	return nil
+}
+
+// newWebhookHandler returns the handler that receives updates from Telegram
+// and hands them to submit. Updates submit refuses are answered with 503, so
+// Telegram delivers them again later.
+func newWebhookHandler(secretToken string, submit func(tgbotapi.Update) bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if secretToken != "" {
+			got := r.Header.Get(secretTokenHeader)
+			if subtle.ConstantTimeCompare([]byte(got), []byte(secretToken)) != 1 {
+				http.Error(w, "invalid secret token", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		var update tgbotapi.Update
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxUpdateSize)).Decode(&update); err != nil {
+			logger.PrintLog(0, "failed to decode webhook update", err)
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, "update too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "invalid update", http.StatusBadRequest)
+			return
+		}
+
+		if !submit(update) {
+			// Telegram retries the update later if we do not acknowledge it
+			http.Error(w, "update not accepted", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
@@ -0,0 +1,114 @@
+package bot
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWebhookHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		secret     string
+		body       string
+		full       bool // The dispatcher refuses the update
+		wantStatus int
+		wantUpdate bool
+	}{
+		{
+			name:       "Valid update",
+			method:     http.MethodPost,
+			secret:     "s3cret",
+			body:       `{"update_id": 10, "message": {"message_id": 1, "text": "hi", "chat": {"id": 42}}}`,
+			wantStatus: http.StatusOK,
+			wantUpdate: true,
+		},
+		{
+			name:       "Dispatcher full",
+			method:     http.MethodPost,
+			secret:     "s3cret",
+			body:       `{"update_id": 14, "message": {"message_id": 1, "text": "hi", "chat": {"id": 42}}}`,
+			full:       true,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "Wrong secret token",
+			method:     http.MethodPost,
+			secret:     "wrong",
+			body:       `{"update_id": 11}`,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Missing secret token",
+			method:     http.MethodPost,
+			body:       `{"update_id": 12}`,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Wrong method",
+			method:     http.MethodGet,
+			secret:     "s3cret",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "Invalid body",
+			method:     http.MethodPost,
+			secret:     "s3cret",
+			body:       `not json`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Body too large",
+			method:     http.MethodPost,
+			secret:     "s3cret",
+			body:       `{"update_id": 13, "message": {"text": "` + strings.Repeat("a", maxUpdateSize) + `"}}`,
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "Body too large without secret token",
+			method:     http.MethodPost,
+			body:       strings.Repeat("a", maxUpdateSize+1),
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updates := make(chan tgbotapi.Update, 1)
+			handler := newWebhookHandler("s3cret", func(update tgbotapi.Update) bool {
+				if tt.full {
+					return false
+				}
+				updates <- update
+				return true
+			})
+
+			req := httptest.NewRequest(tt.method, "/hook", strings.NewReader(tt.body))
+			if tt.secret != "" {
+				req.Header.Set(secretTokenHeader, tt.secret)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+
+			select {
+			case update := <-updates:
+				if !tt.wantUpdate {
+					t.Errorf("expected no update, got %+v", update)
+				} else if update.Message == nil || update.Message.Chat.ID != 42 {
+					t.Errorf("unexpected update: %+v", update)
+				}
+			default:
+				if tt.wantUpdate {
+					t.Errorf("expected an update to be delivered")
+				}
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 	"go-tg-support-ticket/bot"
@@ -8,10 +9,13 @@ import (
 	"go-tg-support-ticket/form"
 	"go-tg-support-ticket/internal/store"
 	"go-tg-support-ticket/logger"
+	"go-tg-support-ticket/storage"
 	"go-tg-support-ticket/webhook"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"runtime"
+	"syscall"
 )
 
 var botCmd = &cobra.Command{
@@ -29,66 +33,68 @@ var botCmd = &cobra.Command{
 
 		logger.Init(cfg.DebugMode)
 
-		if formatFilePath == "" {
+		if len(formatFilePaths) == 0 {
 			color.Set(color.FgYellow)
 			cmd.Println("⚠️ No format file path provided. Showing help...")
 			color.Unset()
 			cmd.Help()
 			return
 		}
-		tf, err := form.LoadTicketFormat(formatFilePath)
+		forms, err := form.LoadForms(formatFilePaths)
 		if err != nil {
 			color.Set(color.FgRed)
-			cmd.PrintErrf("❌ Error loading json format from %s: %v\n", formatFilePath, err)
+			cmd.PrintErrf("❌ Error loading json format: %v\n", err)
 			color.Unset()
 			return
 		}
-		errs, warnings := tf.ValidateForm()
-		showValidationWarnings(cmd, warnings)
-		showValidationErrors(cmd, errs)
 
-		if len(errs) == 0 {
+		if validateForms(cmd, forms) == 0 {
 			color.Set(color.FgGreen)
 			cmd.Println("✅ The JSON file is valid!")
 			color.Unset()
 		}
 
-		if cfg.Database.Enable && cfg.Database.UseAdaptor != tf.DB {
-			color.Set(color.FgRed)
-			cmd.PrintErrf("❌ Configuration mismatch: config uses %s database adaptor, but format specifies %s\n", cfg.Database.UseAdaptor, tf.DB)
-			color.Unset()
-			return
+		for _, tf := range forms {
+			if cfg.Database.Enable && cfg.Database.UseAdaptor != tf.DB {
+				color.Set(color.FgRed)
+				cmd.PrintErrf("❌ Configuration mismatch: config uses %s database adaptor, but format %s specifies %s\n", cfg.Database.UseAdaptor, tf.Slug, tf.DB)
+				color.Unset()
+				return
+			}
 		}
 
 		if cfg.EnableMemoryLoad {
 			color.Set(color.FgGreen)
 			cmd.Println("🔄 Memory load enabled. Trying to load photos...")
 
-			for i := range tf.Fields {
-				if tf.Fields[i].Type == "photo" && tf.Fields[i].Location != "" {
-					color.Set(color.FgCyan)
-					cmd.Printf("🔄 Loading photo: %s...\n", tf.Fields[i].Location)
-					color.Unset()
-
-					data, err := os.ReadFile(filepath.Clean(tf.Fields[i].Location))
-					if err != nil {
-						color.Set(color.FgRed)
-						cmd.PrintErrf("❌ Failed to load %s: %v\n", tf.Fields[i].Location, err)
+		preload:
+			for _, tf := range forms {
+				for i := range tf.Fields {
+					if tf.Fields[i].Type == "photo" && tf.Fields[i].Location != "" {
+						color.Set(color.FgCyan)
+						cmd.Printf("🔄 Loading photo: %s...\n", tf.Fields[i].Location)
 						color.Unset()
-						return
-					}
 
-					tf.Fields[i].PhotoData = data
-					color.Set(color.FgGreen)
-					cmd.Printf("✅ Successfully loaded %s\n", tf.Fields[i].Location)
-					color.Unset()
-
-					// Check memory usage after loading each photo
-					if getMemoryUsageMB() >= cfg.MemoryLimitMB {
-						color.Set(color.FgYellow)
-						cmd.Printf("⚠️ Memory limit reached mid-load. Stopping further preloading!\n")
+						data, err := os.ReadFile(filepath.Clean(tf.Fields[i].Location))
+						if err != nil {
+							color.Set(color.FgRed)
+							cmd.PrintErrf("❌ Failed to load %s: %v\n", tf.Fields[i].Location, err)
+							color.Unset()
+							return
+						}
+
+						tf.Fields[i].PhotoData = data
+						color.Set(color.FgGreen)
+						cmd.Printf("✅ Successfully loaded %s\n", tf.Fields[i].Location)
 						color.Unset()
-						break
+
+						// Check memory usage after loading each photo
+						if getMemoryUsageMB() >= cfg.MemoryLimitMB {
+							color.Set(color.FgYellow)
+							cmd.Printf("⚠️ Memory limit reached mid-load. Stopping further preloading!\n")
+							color.Unset()
+							break preload
+						}
 					}
 				}
 			}
@@ -105,11 +111,30 @@ var botCmd = &cobra.Command{
 				cmd.Println("✅ Connected to the database successfully.")
 				color.Unset()
 			}
+			defer func() {
+				if err := store.Store.Close(); err != nil {
+					color.Set(color.FgRed)
+					cmd.PrintErrf("❌ Failed to close the database: %v\n", err)
+					color.Unset()
+				}
+			}()
+		}
+
+		if err := storage.NewStorage(cfg.Storage); err != nil {
+			color.Set(color.FgRed)
+			cmd.PrintErrf("❌ Failed to set up file storage: %v\n", err)
+			color.Unset()
+			return
 		}
 
-		webhook.NewWebhookWorker(cfg.Webhook)
+		if err := webhook.NewWebhookWorker(cfg.Webhook); err != nil {
+			color.Set(color.FgRed)
+			cmd.PrintErrf("❌ Failed to start webhook workers: %v\n", err)
+			color.Unset()
+			return
+		}
 
-		b, err := bot.NewBot(cfg.Bot, tf)
+		b, err := bot.NewBot(cfg.Bot, forms)
 		if err != nil {
 			color.Set(color.FgRed)
 			cmd.PrintErrf("❌ Error initializing bot: %v\n", err)
@@ -121,13 +146,50 @@ var botCmd = &cobra.Command{
 		cmd.Println("✅ Bot started successfully!")
 		color.Unset()
 
-		b.Start()
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		if err := b.Start(ctx); err != nil {
+			color.Set(color.FgRed)
+			cmd.PrintErrf("❌ Error running bot: %v\n", err)
+			color.Unset()
+		}
+		stop()
+
+		color.Set(color.FgYellow)
+		cmd.Println("🛑 Shutting down...")
+		color.Unset()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+		if err := b.Shutdown(shutdownCtx); err != nil {
+			color.Set(color.FgRed)
+			cmd.PrintErrf("❌ Error stopping bot: %v\n", err)
+			color.Unset()
+		}
+		if webhook.Workers != nil {
+			// After the bot, so the events of the last submissions are sent too
+			shutdownWebhooks(shutdownCtx, cmd)
+		}
 	},
 }
 
+// shutdownWebhooks waits for the queued webhook events to be sent
+func shutdownWebhooks(ctx context.Context, cmd *cobra.Command) {
+	if err := webhook.Workers.Shutdown(ctx); err != nil {
+		color.Set(color.FgRed)
+		cmd.PrintErrf("❌ Error draining webhook queue: %v\n", err)
+		color.Unset()
+		return
+	}
+	color.Set(color.FgGreen)
+	cmd.Println("✅ Webhook queue drained.")
+	color.Unset()
+}
+
 func init() {
 	rootCmd.AddCommand(botCmd)
-	botCmd.Flags().StringVarP(&formatFilePath, "file", "f", "", "Path to format JSON file")
+	botCmd.Flags().StringSliceVarP(&formatFilePaths, "file", "f", nil, "Path to a format JSON file or a directory of them (repeatable)")
 	botCmd.Flags().StringVarP(&configFilePath, "config", "c", "config.yaml", "Path to config JSON file")
 }
 
@@ -13,7 +13,7 @@ var migrateCmd = &cobra.Command{
 	Short: "Run database migrations",
 	Run: func(cmd *cobra.Command, args []string) {
 
-		if formatFilePath == "" {
+		if len(formatFilePaths) == 0 {
 			color.Set(color.FgYellow)
 			cmd.Println("⚠️ Format file path is missing. Showing help...")
 			color.Unset()
@@ -21,10 +21,10 @@ var migrateCmd = &cobra.Command{
 			return
 		}
 
-		tf, err := form.LoadTicketFormat(formatFilePath)
+		forms, err := form.LoadForms(formatFilePaths)
 		if err != nil {
 			color.Set(color.FgRed)
-			cmd.PrintErrf("❌ Error loading ticket format from %s: %v\n", formatFilePath, err)
+			cmd.PrintErrf("❌ Error loading ticket format: %v\n", err)
 			color.Unset()
 			return
 		}
@@ -37,11 +37,13 @@ var migrateCmd = &cobra.Command{
 			return
 		}
 
-		if cfg.Database.Enable && cfg.Database.UseAdaptor != tf.DB {
-			color.Set(color.FgRed)
-			cmd.PrintErrf("❌ Database configuration mismatch: config uses %s adaptor, but format specifies %s DB\n", cfg.Database.UseAdaptor, tf.DB)
-			color.Unset()
-			return
+		for _, tf := range forms {
+			if cfg.Database.Enable && cfg.Database.UseAdaptor != tf.DB {
+				color.Set(color.FgRed)
+				cmd.PrintErrf("❌ Database configuration mismatch: config uses %s adaptor, but format %s specifies %s DB\n", cfg.Database.UseAdaptor, tf.Slug, tf.DB)
+				color.Unset()
+				return
+			}
 		}
 
 		if !cfg.Database.Enable {
@@ -61,6 +63,7 @@ var migrateCmd = &cobra.Command{
 			color.Unset()
 			return
 		}
+		defer store.Store.Close()
 
 		color.Set(color.FgGreen)
 		cmd.Println("✅ Database connected successfully.")
@@ -70,15 +73,15 @@ var migrateCmd = &cobra.Command{
 		cmd.Println("🔄 Running database migration...")
 		color.Unset()
 
-		errs, warnings := tf.ValidateForm()
-		showValidationWarnings(cmd, warnings)
-		showValidationErrors(cmd, errs)
+		validateForms(cmd, forms)
 
-		if err := store.Store.Migrate(tf); err != nil {
-			color.Set(color.FgRed)
-			cmd.PrintErrf("❌ Migration failed: %v\n", err)
-			color.Unset()
-			return
+		for _, tf := range forms {
+			if err := store.Store.Migrate(tf); err != nil {
+				color.Set(color.FgRed)
+				cmd.PrintErrf("❌ Migration of %s failed: %v\n", tf.Slug, err)
+				color.Unset()
+				return
+			}
 		}
 
 		color.Set(color.FgGreen)
@@ -89,6 +92,6 @@ var migrateCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(migrateCmd)
-	migrateCmd.Flags().StringVarP(&formatFilePath, "file", "f", "", "Path to format JSON file")
+	migrateCmd.Flags().StringSliceVarP(&formatFilePaths, "file", "f", nil, "Path to a format JSON file or a directory of them (repeatable)")
 	migrateCmd.Flags().StringVarP(&configFilePath, "config", "c", "config.yaml", "Path to config JSON file")
 }
@@ -7,7 +7,7 @@ import (
 	"os"
 )
 
-var formatFilePath string
+var formatFilePaths []string
 var configFilePath string
 
 var rootCmd = &cobra.Command{
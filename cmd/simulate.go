@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"go-tg-support-ticket/form"
+	"go-tg-support-ticket/scenario"
+	"os"
+)
+
+var simulateForm string
+
+func init() {
+	rootCmd.AddCommand(simulateCmd)
+	simulateCmd.Flags().StringSliceVarP(&formatFilePaths, "file", "f", nil, "Path to a format JSON file or a directory of them (repeatable)")
+	simulateCmd.Flags().StringVar(&simulateForm, "form", "", "Slug of the form to start; shows the form menu if empty")
+}
+
+var simulateCmd = &cobra.Command{
+	Use:           "simulate",
+	Short:         "Fill in a form in the terminal without a bot token",
+	Long:          "Run the form flow in the terminal with a fake Telegram server and print the values that would be stored and sent to the webhook",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if len(formatFilePaths) == 0 {
+			color.Set(color.FgYellow)
+			cmd.Println("⚠️ Format file path is missing. Showing help...")
+			color.Unset()
+			return cmd.Help()
+		}
+
+		forms, err := form.LoadForms(formatFilePaths)
+		if err != nil {
+			return fmt.Errorf("invalid JSON file: %w", err)
+		}
+		if validateForms(cmd, forms) > 0 {
+			return fmt.Errorf("the format file is invalid")
+		}
+
+		start := "/start"
+		if simulateForm != "" {
+			start += " " + simulateForm
+		}
+		sim := scenario.Simulator{Forms: forms, Start: start, In: os.Stdin, Out: cmd.OutOrStdout()}
+		return sim.Run()
+	},
+}
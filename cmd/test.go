@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"go-tg-support-ticket/form"
+	"go-tg-support-ticket/scenario"
+	"time"
+)
+
+var scenarioTimeout time.Duration
+
+func init() {
+	rootCmd.AddCommand(testCmd)
+	testCmd.Flags().StringSliceVarP(&formatFilePaths, "file", "f", nil, "Path to a format JSON file or a directory of them (repeatable)")
+	testCmd.Flags().DurationVarP(&scenarioTimeout, "timeout", "t", scenario.DefaultTimeout, "How long each step waits for an expected reply")
+}
+
+var testCmd = &cobra.Command{
+	Use:           "test [scenario files or directories]",
+	Short:         "Run scripted conversations against the forms",
+	Long:          "Run YAML scenarios of user inputs against the forms with a fake Telegram server and check the replies and the stored and webhook values",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if len(formatFilePaths) == 0 || len(args) == 0 {
+			color.Set(color.FgYellow)
+			cmd.Println("⚠️ Format file path or scenario files are missing. Showing help...")
+			color.Unset()
+			return cmd.Help()
+		}
+
+		forms, err := form.LoadForms(formatFilePaths)
+		if err != nil {
+			return fmt.Errorf("invalid JSON file: %w", err)
+		}
+		if validateForms(cmd, forms) > 0 {
+			return fmt.Errorf("the format file is invalid")
+		}
+
+		scenarios, err := scenario.Load(args)
+		if err != nil {
+			return err
+		}
+
+		runner := scenario.Runner{Forms: forms, Timeout: scenarioTimeout}
+		var failed int
+		for _, sc := range scenarios {
+			res := runner.Run(sc)
+			if res.Passed() {
+				color.Set(color.FgGreen)
+				cmd.Printf("✅ %s\n", sc.Name)
+				color.Unset()
+				continue
+			}
+
+			failed++
+			color.Set(color.FgRed)
+			cmd.Printf("❌ %s (%s)\n", sc.Name, sc.Path)
+			for _, failure := range res.Failures {
+				cmd.Printf("   - %s\n", failure)
+			}
+			color.Unset()
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d of %d scenarios failed", failed, len(scenarios))
+		}
+		color.Set(color.FgGreen)
+		cmd.Printf("✅ All %d scenarios passed!\n", len(scenarios))
+		color.Unset()
+		return nil
+	},
+}
@@ -8,7 +8,7 @@ import (
 
 func init() {
 	rootCmd.AddCommand(validateCmd)
-	validateCmd.Flags().StringVarP(&formatFilePath, "file", "f", "", "Path to format JSON file")
+	validateCmd.Flags().StringSliceVarP(&formatFilePaths, "file", "f", nil, "Path to a format JSON file or a directory of them (repeatable)")
 }
 
 var validateCmd = &cobra.Command{
@@ -16,7 +16,7 @@ var validateCmd = &cobra.Command{
 	Short: "Validate a JSON file",
 	Run: func(cmd *cobra.Command, args []string) {
 
-		if formatFilePath == "" {
+		if len(formatFilePaths) == 0 {
 			color.Set(color.FgYellow)
 			cmd.Println("⚠️ Format file path is missing. Showing help...")
 			color.Unset()
@@ -24,7 +24,7 @@ var validateCmd = &cobra.Command{
 			return
 		}
 
-		tf, err := form.LoadTicketFormat(formatFilePath)
+		forms, err := form.LoadForms(formatFilePaths)
 		if err != nil {
 			color.Set(color.FgRed)
 			cmd.PrintErrf("❌ Invalid JSON file: %v\n", err)
@@ -32,11 +32,7 @@ var validateCmd = &cobra.Command{
 			return
 		}
 
-		errs, warnings := tf.ValidateForm()
-		showValidationWarnings(cmd, warnings)
-		showValidationErrors(cmd, errs)
-
-		if len(errs) == 0 {
+		if validateForms(cmd, forms) == 0 {
 			color.Set(color.FgGreen)
 			cmd.Println("✅ The JSON file is valid!")
 			color.Unset()
@@ -45,6 +41,28 @@ var validateCmd = &cobra.Command{
 	},
 }
 
+// validateForms shows the validation results of every form and returns the number of errors
+func validateForms(cmd *cobra.Command, forms []*form.Form) int {
+	var count int
+	for _, tf := range forms {
+		if len(forms) > 1 {
+			color.Set(color.FgCyan)
+			cmd.Printf("📄 Form %s (%s)\n", tf.FormName, tf.Slug)
+			color.Unset()
+		}
+		errs, warnings := tf.ValidateForm()
+		showValidationWarnings(cmd, warnings)
+		showValidationErrors(cmd, errs)
+		count += len(errs)
+	}
+
+	if errs := form.ValidateForms(forms); len(errs) > 0 {
+		showValidationErrors(cmd, errs)
+		count += len(errs)
+	}
+	return count
+}
+
 func showValidationErrors(cmd *cobra.Command, errors []error) {
 	if len(errors) > 0 {
 		// Show all errors in a formatted way
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"go-tg-support-ticket/config"
+	"go-tg-support-ticket/logger"
+	"go-tg-support-ticket/webhook"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+)
+
+var replayAll bool
+
+func init() {
+	rootCmd.AddCommand(webhookCmd)
+	webhookCmd.PersistentFlags().StringVarP(&configFilePath, "config", "c", "config.yaml", "Path to config JSON file")
+	webhookCmd.AddCommand(listFailedCmd, replayCmd)
+	replayCmd.Flags().BoolVar(&replayAll, "all", false, "Replay all failed events")
+}
+
+var webhookCmd = &cobra.Command{
+	Use:   "webhook",
+	Short: "Inspect and replay webhook events that could not be delivered",
+}
+
+var listFailedCmd = &cobra.Command{
+	Use:   "list-failed",
+	Short: "List the events of the dead-letter store",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.LoadConfig(configFilePath)
+		if err != nil {
+			color.Set(color.FgRed)
+			cmd.PrintErrf("❌ Error loading configuration: %v\n", err)
+			color.Unset()
+			return
+		}
+
+		records, err := webhook.ListFailed(cfg.Webhook)
+		if err != nil {
+			color.Set(color.FgRed)
+			cmd.PrintErrf("❌ Failed to read the dead-letter store: %v\n", err)
+			color.Unset()
+			return
+		}
+		if len(records) == 0 {
+			color.Set(color.FgGreen)
+			cmd.Println("✅ No failed webhook events.")
+			color.Unset()
+			return
+		}
+
+		tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tTYPE\tEVENT\tENDPOINT\tCREATED\tFAILED\tATTEMPTS\tLAST ERROR")
+		for _, r := range records {
+			endpoint := r.Endpoint
+			if endpoint == "" {
+				endpoint = webhook.DefaultEndpoint
+			}
+			eventType, name := describeRecord(r)
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%d\t%s\n", r.ID, eventType, name, endpoint, r.CreatedAt.Local().Format(time.DateTime), r.FailedAt.Local().Format(time.DateTime), r.Attempts, r.LastError)
+		}
+		tw.Flush()
+	},
+}
+
+var replayCmd = &cobra.Command{
+	Use:   "replay <id>... | --all",
+	Short: "Send failed events again",
+	Run: func(cmd *cobra.Command, args []string) {
+		if (len(args) > 0) == replayAll {
+			color.Set(color.FgYellow)
+			cmd.Println("⚠️ Give the IDs of the events to replay or --all. Showing help...")
+			color.Unset()
+			cmd.Help()
+			return
+		}
+
+		cfg, err := config.LoadConfig(configFilePath)
+		if err != nil {
+			color.Set(color.FgRed)
+			cmd.PrintErrf("❌ Error loading configuration: %v\n", err)
+			color.Unset()
+			return
+		}
+		logger.Init(cfg.DebugMode)
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		results, err := webhook.Replay(ctx, cfg.Webhook, args)
+		if err != nil {
+			color.Set(color.FgRed)
+			cmd.PrintErrf("❌ Failed to replay webhook events: %v\n", err)
+			color.Unset()
+			return
+		}
+
+		var failed int
+		for _, res := range results {
+			if res.Err != nil {
+				failed++
+				color.Set(color.FgRed)
+				_, name := describeRecord(res.Record)
+				cmd.PrintErrf("❌ %s (%s): %v\n", res.Record.ID, name, res.Err)
+				color.Unset()
+				continue
+			}
+			color.Set(color.FgGreen)
+			_, name := describeRecord(res.Record)
+			cmd.Printf("✅ %s (%s) delivered\n", res.Record.ID, name)
+			color.Unset()
+		}
+
+		if failed > 0 {
+			color.Set(color.FgYellow)
+			cmd.Printf("⚠️ %d of %d events are still in the dead-letter store.\n", failed, len(results))
+			color.Unset()
+		} else if len(results) == 0 {
+			color.Set(color.FgGreen)
+			cmd.Println("✅ No failed webhook events.")
+			color.Unset()
+		}
+	},
+}
+
+// describeRecord returns the type and the form name of a dead-lettered event
+func describeRecord(r *webhook.Record) (string, string) {
+	e, err := r.Event()
+	if err != nil {
+		return "?", "?"
+	}
+	return e.Type, e.Event
+}
@@ -4,20 +4,27 @@ import (
 	"fmt"
 	"github.com/spf13/viper"
 	"go-tg-support-ticket/bot"
+	"go-tg-support-ticket/storage"
 	"go-tg-support-ticket/webhook"
+	"time"
 
 	"go-tg-support-ticket/internal/database"
 )
 
+const defaultShutdownTimeout = 30 * time.Second
+
 type Config struct {
 	DebugMode bool `mapstructure:"debug_mode"`
 
 	EnableMemoryLoad bool  `mapstructure:"enable_memory_load"`
 	MemoryLimitMB    int64 `mapstructure:"memory_limit_mb"`
 
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"` // How long to wait for handlers and webhooks on exit
+
 	Bot      *bot.Config      `mapstructure:"bot" validate:"required"`
 	Database *database.Config `mapstructure:"database"`
 	Webhook  *webhook.Config  `mapstructure:"webhook"`
+	Storage  *storage.Config  `mapstructure:"storage"`
 }
 
 func LoadConfig(configPath string) (*Config, error) {
@@ -33,5 +40,20 @@ func LoadConfig(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to unmarshal configuration: %w", err)
 	}
 
+	if cfg.ShutdownTimeout <= 0 {
+		cfg.ShutdownTimeout = defaultShutdownTimeout
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
 	return &cfg, nil
 }
+
+// validate checks settings that depend on each other
+func (c *Config) validate() error {
+	if c.Bot != nil && c.Bot.SessionStore == "database" && (c.Database == nil || !c.Database.Enable) {
+		return fmt.Errorf("bot.session_store \"database\" requires database.enable to be true")
+	}
+	return nil
+}
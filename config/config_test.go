@@ -0,0 +1,33 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfigSessionStore(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr bool
+	}{
+		{name: "Memory store", yaml: "bot:\n  session_store: memory\n"},
+		{name: "Database store", yaml: "bot:\n  session_store: database\ndatabase:\n  enable: true\n"},
+		{name: "Database store with database disabled", yaml: "bot:\n  session_store: database\ndatabase:\n  enable: false\n", wantErr: true},
+		{name: "Database store without database", yaml: "bot:\n  session_store: database\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(tt.yaml), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadConfig(path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
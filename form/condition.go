@@ -0,0 +1,238 @@
+package form
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// EndOfForm can be used as a `goto` target to jump straight to review/submit
+const EndOfForm = "end"
+
+// Condition is a rule evaluated against the answers given so far.
+// All operators set on a condition must hold; `and`/`or` combine nested conditions.
+type Condition struct {
+	Field  string      `json:"field,omitempty"`
+	Equals *string     `json:"equals,omitempty"`
+	In     []string    `json:"in,omitempty"`
+	Regex  string      `json:"regex,omitempty"`
+	GT     *float64    `json:"gt,omitempty"`
+	GTE    *float64    `json:"gte,omitempty"`
+	LT     *float64    `json:"lt,omitempty"`
+	LTE    *float64    `json:"lte,omitempty"`
+	And    []Condition `json:"and,omitempty"`
+	Or     []Condition `json:"or,omitempty"`
+}
+
+// NextRule sends the user to the `goto` field when `if` matches (or always, if `if` is omitted)
+type NextRule struct {
+	If   *Condition `json:"if,omitempty"`
+	Goto string     `json:"goto"`
+}
+
+// Evaluate reports whether the condition holds for the given answers
+func (c *Condition) Evaluate(answers map[string]string) bool {
+	for i := range c.And {
+		if !c.And[i].Evaluate(answers) {
+			return false
+		}
+	}
+	if len(c.Or) > 0 {
+		matched := false
+		for i := range c.Or {
+			if c.Or[i].Evaluate(answers) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if c.Field == "" {
+		return true
+	}
+	value := answers[c.Field]
+
+	if c.Equals != nil && value != *c.Equals {
+		return false
+	}
+	if len(c.In) > 0 && !contains(c.In, value) {
+		return false
+	}
+	if c.Regex != "" {
+		matched, err := regexp.MatchString(c.Regex, value)
+		if err != nil || !matched {
+			return false
+		}
+	}
+	if c.GT != nil || c.GTE != nil || c.LT != nil || c.LTE != nil {
+		num, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return false
+		}
+		if (c.GT != nil && !(num > *c.GT)) ||
+			(c.GTE != nil && !(num >= *c.GTE)) ||
+			(c.LT != nil && !(num < *c.LT)) ||
+			(c.LTE != nil && !(num <= *c.LTE)) {
+			return false
+		}
+	}
+	return true
+}
+
+// validate checks that the condition only references known fields and valid regexes
+func (c *Condition) validate(owner string, known map[string]int) []error {
+	var errs []error
+	if c.Field != "" {
+		if _, ok := known[c.Field]; !ok {
+			errs = append(errs, fmt.Errorf("field '%s' has a condition on unknown field '%s'", owner, c.Field))
+		}
+	}
+	if c.Regex != "" {
+		if _, err := regexp.Compile(c.Regex); err != nil {
+			errs = append(errs, fmt.Errorf("field '%s' has a condition with an invalid regex pattern", owner))
+		}
+	}
+	for i := range c.And {
+		errs = append(errs, c.And[i].validate(owner, known)...)
+	}
+	for i := range c.Or {
+		errs = append(errs, c.Or[i].validate(owner, known)...)
+	}
+	return errs
+}
+
+// fieldIndex returns the position of the named field, or -1
+func (f *Form) fieldIndex(name string) int {
+	for i, field := range f.Fields {
+		if field.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// visibleFrom returns the first field at or after step whose `show_if` holds.
+// It returns len(f.Fields) once the form is finished.
+func (f *Form) visibleFrom(step int, answers map[string]string) int {
+	for step < len(f.Fields) {
+		if cond := f.Fields[step].ShowIf; cond == nil || cond.Evaluate(answers) {
+			return step
+		}
+		step++
+	}
+	return len(f.Fields)
+}
+
+// FirstStep returns the index of the first field to ask
+func (f *Form) FirstStep(answers map[string]string) int {
+	return f.visibleFrom(0, answers)
+}
+
+// NextStep picks the field to ask after the current one, following the `next`
+// rules of the current field and skipping fields hidden by `show_if`.
+// It returns len(f.Fields) once the form is finished.
+func (f *Form) NextStep(current int, answers map[string]string) int {
+	if current < 0 || current >= len(f.Fields) {
+		return len(f.Fields)
+	}
+
+	next := current + 1
+	for _, rule := range f.Fields[current].Next {
+		if rule.If == nil || rule.If.Evaluate(answers) {
+			if rule.Goto == EndOfForm {
+				return len(f.Fields)
+			}
+			if i := f.fieldIndex(rule.Goto); i >= 0 {
+				next = i
+			}
+			break
+		}
+	}
+	return f.visibleFrom(next, answers)
+}
+
+// Path returns the indexes of the fields a user with the given answers goes through
+func (f *Form) Path(answers map[string]string) []int {
+	var path []int
+	seen := make(map[int]bool)
+	for step := f.FirstStep(answers); step < len(f.Fields) && !seen[step]; step = f.NextStep(step, answers) {
+		seen[step] = true
+		path = append(path, step)
+	}
+	return path
+}
+
+// validateFlow checks `show_if` and `next` references and rejects flows with cycles
+func (f *Form) validateFlow() []error {
+	var errs []error
+
+	known := make(map[string]int, len(f.Fields))
+	for i, field := range f.Fields {
+		known[field.Name] = i
+	}
+
+	// edges[i] holds every step that can follow step i
+	edges := make([][]int, len(f.Fields))
+	for i, field := range f.Fields {
+		if field.ShowIf != nil {
+			errs = append(errs, field.ShowIf.validate(field.Name, known)...)
+		}
+
+		fallthroughNext := true
+		for _, rule := range field.Next {
+			if rule.If != nil {
+				errs = append(errs, rule.If.validate(field.Name, known)...)
+			} else {
+				fallthroughNext = false
+			}
+
+			if rule.Goto == EndOfForm {
+				continue
+			}
+			target, ok := known[rule.Goto]
+			if !ok {
+				errs = append(errs, fmt.Errorf("field '%s' has a next rule to unknown field '%s'", field.Name, rule.Goto))
+				continue
+			}
+			edges[i] = append(edges[i], target)
+		}
+		// A field hidden by show_if passes on to the next field without
+		// following its next rules
+		if (fallthroughNext || field.ShowIf != nil) && i+1 < len(f.Fields) {
+			edges[i] = append(edges[i], i+1)
+		}
+	}
+
+	// Depth-first search for back edges
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make([]int, len(f.Fields))
+	var visit func(i int) bool
+	visit = func(i int) bool {
+		state[i] = visiting
+		for _, j := range edges[i] {
+			if state[j] == visiting {
+				errs = append(errs, fmt.Errorf("form flow has a cycle between '%s' and '%s'", f.Fields[i].Name, f.Fields[j].Name))
+				return true
+			}
+			if state[j] == unvisited && visit(j) {
+				return true
+			}
+		}
+		state[i] = done
+		return false
+	}
+	for i := range f.Fields {
+		if state[i] == unvisited && visit(i) {
+			break
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,177 @@
+package form
+
+import (
+	"strings"
+	"testing"
+)
+
+func strPtr(s string) *string   { return &s }
+func numPtr(f float64) *float64 { return &f }
+
+func TestConditionEvaluate(t *testing.T) {
+	answers := map[string]string{
+		"category": "billing",
+		"amount":   "150",
+		"email":    "jane@example.com",
+	}
+
+	tests := []struct {
+		name      string
+		condition Condition
+		want      bool
+	}{
+		{"equals match", Condition{Field: "category", Equals: strPtr("billing")}, true},
+		{"equals mismatch", Condition{Field: "category", Equals: strPtr("technical")}, false},
+		{"in match", Condition{Field: "category", In: []string{"billing", "sales"}}, true},
+		{"in mismatch", Condition{Field: "category", In: []string{"technical"}}, false},
+		{"regex match", Condition{Field: "email", Regex: `@example\.com$`}, true},
+		{"regex mismatch", Condition{Field: "email", Regex: `@corp\.com$`}, false},
+		{"greater than", Condition{Field: "amount", GT: numPtr(100)}, true},
+		{"range", Condition{Field: "amount", GTE: numPtr(100), LTE: numPtr(150)}, true},
+		{"less than fails", Condition{Field: "amount", LT: numPtr(100)}, false},
+		{"numeric on text fails", Condition{Field: "category", GT: numPtr(0)}, false},
+		{"missing answer", Condition{Field: "unknown", Equals: strPtr("x")}, false},
+		{
+			"and",
+			Condition{And: []Condition{
+				{Field: "category", Equals: strPtr("billing")},
+				{Field: "amount", GT: numPtr(100)},
+			}},
+			true,
+		},
+		{
+			"and with one false",
+			Condition{And: []Condition{
+				{Field: "category", Equals: strPtr("billing")},
+				{Field: "amount", GT: numPtr(500)},
+			}},
+			false,
+		},
+		{
+			"or",
+			Condition{Or: []Condition{
+				{Field: "category", Equals: strPtr("technical")},
+				{Field: "amount", GT: numPtr(100)},
+			}},
+			true,
+		},
+		{
+			"or with none true",
+			Condition{Or: []Condition{
+				{Field: "category", Equals: strPtr("technical")},
+				{Field: "amount", GT: numPtr(500)},
+			}},
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.condition.Evaluate(answers); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNextStep(t *testing.T) {
+	f := &Form{
+		Fields: []Field{
+			{Name: "category", Next: []NextRule{
+				{If: &Condition{Field: "category", Equals: strPtr("technical")}, Goto: "device"},
+			}},
+			{Name: "invoice", ShowIf: &Condition{Field: "category", Equals: strPtr("billing")}},
+			{Name: "refund", Next: []NextRule{{Goto: EndOfForm}}},
+			{Name: "device"},
+			{Name: "email"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		answers  map[string]string
+		wantPath []string
+	}{
+		{"billing", map[string]string{"category": "billing"}, []string{"category", "invoice", "refund"}},
+		{"technical", map[string]string{"category": "technical"}, []string{"category", "device", "email"}},
+		{"other skips hidden field", map[string]string{"category": "other"}, []string{"category", "refund"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, step := range f.Path(tt.answers) {
+				got = append(got, f.Fields[step].Name)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.wantPath, ",") {
+				t.Errorf("expected path %v, got %v", tt.wantPath, got)
+			}
+		})
+	}
+}
+
+func TestValidateFlow(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  []Field
+		wantErr string
+	}{
+		{
+			name: "valid flow",
+			fields: []Field{
+				{Name: "a", Next: []NextRule{{If: &Condition{Field: "a", Equals: strPtr("x")}, Goto: "c"}}},
+				{Name: "b"},
+				{Name: "c"},
+			},
+		},
+		{
+			name:    "unknown condition field",
+			fields:  []Field{{Name: "a", ShowIf: &Condition{Field: "missing", Equals: strPtr("x")}}},
+			wantErr: "unknown field 'missing'",
+		},
+		{
+			name:    "unknown goto target",
+			fields:  []Field{{Name: "a", Next: []NextRule{{Goto: "missing"}}}},
+			wantErr: "next rule to unknown field 'missing'",
+		},
+		{
+			name: "cycle",
+			fields: []Field{
+				{Name: "a"},
+				{Name: "b", Next: []NextRule{{If: &Condition{Field: "b", Equals: strPtr("again")}, Goto: "a"}}},
+			},
+			wantErr: "cycle",
+		},
+		{
+			name: "cycle through a hidden field",
+			fields: []Field{
+				{Name: "a"},
+				{Name: "b", ShowIf: &Condition{Field: "a", Equals: strPtr("x")}, Next: []NextRule{{Goto: EndOfForm}}},
+				{Name: "c", Next: []NextRule{{Goto: "a"}}},
+			},
+			wantErr: "cycle",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &Form{Fields: tt.fields}
+			errs := f.validateFlow()
+			if tt.wantErr == "" {
+				if len(errs) != 0 {
+					t.Errorf("expected no errors, got %v", errs)
+				}
+				return
+			}
+			found := false
+			for _, err := range errs {
+				if strings.Contains(err.Error(), tt.wantErr) {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("expected an error containing %q, got %v", tt.wantErr, errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package form
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Field types holding a point in time
+const (
+	DateType     = "date"
+	TimeType     = "time"
+	DateTimeType = "datetime"
+)
+
+// ISO-8601 layouts the values of date, time and datetime fields are stored in
+const (
+	DateLayout     = "2006-01-02"
+	TimeLayout     = "15:04:05"
+	DateTimeLayout = time.RFC3339
+)
+
+// Layouts accepted from users unless the field sets validation.layouts.
+// Numeric dates are read day first (31/12/2025).
+var (
+	dateLayouts = []string{
+		"2006-01-02", "2006/01/02", "02.01.2006", "02/01/2006", "02-01-2006",
+		"2.1.2006", "2/1/2006", "2 Jan 2006", "2 January 2006", "Jan 2 2006",
+		"January 2 2006", "Jan 2, 2006", "January 2, 2006",
+	}
+	timeLayouts = []string{
+		"15:04", "15:04:05", "3:04PM", "3:04 PM", "3:04pm", "3:04 pm", "3PM", "3 PM", "3pm", "3 pm",
+	}
+	dateTimeLayouts = func() []string {
+		layouts := []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04"}
+		for _, d := range dateLayouts {
+			for _, t := range timeLayouts {
+				layouts = append(layouts, d+" "+t, d+", "+t)
+			}
+		}
+		return layouts
+	}()
+)
+
+// relativeDateRegex matches bounds like "today", "today+30d", "now-2h" or "today+1y"
+var relativeDateRegex = regexp.MustCompile(`^(today|now)(?:([+-]\d+)([hdwmy]))?$`)
+
+// IsTemporal reports whether the field holds a date, time or datetime
+func (f Field) IsTemporal() bool {
+	return f.Type == DateType || f.Type == TimeType || f.Type == DateTimeType
+}
+
+// layouts returns the layouts accepted for the field
+func (f Field) layouts() []string {
+	if len(f.Validation.Layouts) > 0 {
+		return f.Validation.Layouts
+	}
+	switch f.Type {
+	case DateType:
+		return dateLayouts
+	case TimeType:
+		return timeLayouts
+	default:
+		return dateTimeLayouts
+	}
+}
+
+// ParseTemporal parses a user value of a date, time or datetime field.
+// Values without a time zone are read in loc.
+func (f Field) ParseTemporal(value string, loc *time.Location) (time.Time, error) {
+	value = strings.Join(strings.Fields(value), " ")
+	for _, layout := range f.layouts() {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("'%s' is not a valid %s", value, f.Type)
+}
+
+// FormatTemporal returns the ISO-8601 representation of t for the field type
+func (f Field) FormatTemporal(t time.Time) string {
+	switch f.Type {
+	case DateType:
+		return t.Format(DateLayout)
+	case TimeType:
+		return t.Format(TimeLayout)
+	default:
+		return t.Format(DateTimeLayout)
+	}
+}
+
+// DateBounds resolves validation.min_date and validation.max_date at now.
+// A nil bound means there is no limit.
+func (f Field) DateBounds(now time.Time) (min, max *time.Time, err error) {
+	if f.Validation.MinDate != "" {
+		t, err := f.resolveBound(f.Validation.MinDate, now)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid min_date of field '%s': %w", f.Name, err)
+		}
+		min = &t
+	}
+	if f.Validation.MaxDate != "" {
+		t, err := f.resolveBound(f.Validation.MaxDate, now)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid max_date of field '%s': %w", f.Name, err)
+		}
+		max = &t
+	}
+	return min, max, nil
+}
+
+// resolveBound turns a bound into a time. Bounds are either relative to now
+// ("today+30d") or written like a value of the field in ISO-8601.
+func (f Field) resolveBound(bound string, now time.Time) (time.Time, error) {
+	loc := now.Location()
+	if m := relativeDateRegex.FindStringSubmatch(strings.ToLower(bound)); m != nil {
+		t := now
+		if m[1] == "today" {
+			t = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+		}
+		if m[2] == "" {
+			return t, nil
+		}
+		n, _ := strconv.Atoi(m[2])
+		switch m[3] {
+		case "h":
+			return t.Add(time.Duration(n) * time.Hour), nil
+		case "d":
+			return t.AddDate(0, 0, n), nil
+		case "w":
+			return t.AddDate(0, 0, 7*n), nil
+		case "m":
+			return t.AddDate(0, n, 0), nil
+		default:
+			return t.AddDate(n, 0, 0), nil
+		}
+	}
+
+	var layouts []string
+	switch f.Type {
+	case TimeType:
+		layouts = []string{TimeLayout, "15:04"}
+	case DateType:
+		layouts = []string{DateLayout}
+	default:
+		layouts = []string{DateTimeLayout, "2006-01-02T15:04:05", "2006-01-02T15:04", DateLayout}
+	}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, bound, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("'%s' is neither ISO-8601 nor relative like 'today+30d'", bound)
+}
+
+// InRange checks t against the bounds resolved at now. Time fields are
+// compared by time of day only. It returns the violated bound, formatted
+// like a value of the field, or "" if t is in range.
+func (f Field) InRange(t, now time.Time) (tooEarly, tooLate string, err error) {
+	min, max, err := f.DateBounds(now)
+	if err != nil {
+		return "", "", err
+	}
+
+	key := func(v time.Time) time.Time { return v }
+	if f.Type == TimeType {
+		key = func(v time.Time) time.Time {
+			return time.Date(0, 1, 1, v.Hour(), v.Minute(), v.Second(), 0, time.UTC)
+		}
+	}
+	if min != nil && key(t).Before(key(*min)) {
+		return f.FormatTemporal(*min), "", nil
+	}
+	if max != nil && key(t).After(key(*max)) {
+		return "", f.FormatTemporal(*max), nil
+	}
+	return "", "", nil
+}
+
+// Location returns the time zone of the form, used for values without one
+// and for "today". It falls back to the local time zone.
+func (f *Form) Location() *time.Location {
+	if f.Timezone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(f.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// validateTemporal checks the settings specific to date, time and datetime fields
+func validateTemporal(field Field) []error {
+	var errs []error
+	if field.Calendar && field.Type != DateType {
+		errs = append(errs, fmt.Errorf("field '%s' can only show a calendar if it is of type 'date'", field.Name))
+	}
+	min, max, err := field.DateBounds(time.Now())
+	if err != nil {
+		errs = append(errs, err)
+	} else if min != nil && max != nil && min.After(*max) {
+		errs = append(errs, fmt.Errorf("field '%s' has min_date after max_date", field.Name))
+	}
+	return errs
+}
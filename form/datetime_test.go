@@ -0,0 +1,78 @@
+package form
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTemporal(t *testing.T) {
+	loc := time.FixedZone("UTC+7", 7*3600)
+	tests := []struct {
+		fieldType string
+		input     string
+		want      string
+		wantErr   bool
+	}{
+		{DateType, "2025-12-31", "2025-12-31", false},
+		{DateType, "31.12.2025", "2025-12-31", false},
+		{DateType, "1/2/2025", "2025-02-01", false},
+		{DateType, "Dec 31, 2025", "2025-12-31", false},
+		{DateType, "2025-02-30", "", true},
+		{TimeType, "14:30", "14:30:00", false},
+		{TimeType, "2:30 pm", "14:30:00", false},
+		{TimeType, "25:00", "", true},
+		{DateTimeType, "2025-12-31  14:30", "2025-12-31T14:30:00+07:00", false},
+		{DateTimeType, "2025-12-31T14:30:00Z", "2025-12-31T14:30:00Z", false},
+		{DateTimeType, "2025-12-31", "", true},
+	}
+
+	for _, test := range tests {
+		field := Field{Name: "f", Type: test.fieldType}
+		got, err := field.ParseTemporal(test.input, loc)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%s %q: expected an error", test.fieldType, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %q: %v", test.fieldType, test.input, err)
+			continue
+		}
+		if s := field.FormatTemporal(got); s != test.want {
+			t.Errorf("%s %q: got %s, want %s", test.fieldType, test.input, s, test.want)
+		}
+	}
+}
+
+func TestDateBounds(t *testing.T) {
+	now := time.Date(2025, 1, 31, 15, 4, 5, 0, time.UTC)
+	tests := []struct {
+		bound string
+		want  string
+	}{
+		{"today", "2025-01-31T00:00:00Z"},
+		{"today+30d", "2025-03-02T00:00:00Z"},
+		{"Today-1w", "2025-01-24T00:00:00Z"},
+		{"today+1m", "2025-03-03T00:00:00Z"},
+		{"now+2h", "2025-01-31T17:04:05Z"},
+		{"2025-06-01", "2025-06-01T00:00:00Z"},
+	}
+
+	for _, test := range tests {
+		field := Field{Name: "f", Type: DateTimeType, Validation: Validation{MinDate: test.bound}}
+		min, _, err := field.DateBounds(now)
+		if err != nil {
+			t.Errorf("%s: %v", test.bound, err)
+			continue
+		}
+		if got := min.Format(time.RFC3339); got != test.want {
+			t.Errorf("%s: got %s, want %s", test.bound, got, test.want)
+		}
+	}
+
+	field := Field{Name: "f", Type: DateType, Validation: Validation{MaxDate: "tomorrow"}}
+	if _, _, err := field.DateBounds(now); err == nil {
+		t.Error("expected an error for an unknown bound")
+	}
+}
@@ -0,0 +1,171 @@
+package form
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FileType is the field type of file uploads
+const FileType = "file"
+
+// File is one upload of a "file" field. The file is identified by its
+// Telegram file_id and, when a file storage is configured, copied there
+// under Ref. Download URLs of the Bot API embed the bot token, so they are
+// never kept.
+type File struct {
+	FileID       string `json:"file_id"`
+	FileUniqueID string `json:"file_unique_id,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Size         int64  `json:"size"`
+	MimeType     string `json:"mime_type,omitempty"`
+	SHA256       string `json:"sha256,omitempty"`
+	Ref          string `json:"ref,omitempty"` // Stable reference of the stored copy, e.g. "s3://bucket/key"
+}
+
+// Files decodes the value of a "file" field
+func (f Field) Files() ([]File, error) {
+	if f.IsNull() {
+		return nil, nil
+	}
+	var files []File
+	if err := json.Unmarshal([]byte(f.UserValue), &files); err != nil {
+		return nil, fmt.Errorf("failed to decode files of field '%s': %w", f.Name, err)
+	}
+	return files, nil
+}
+
+// ByteSize is a file size in bytes. Format files give it as a number of
+// bytes or as a string with a unit, e.g. "10MB" or "512 KB".
+type ByteSize int64
+
+var byteUnits = []struct {
+	suffix string
+	size   ByteSize
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// ParseByteSize parses sizes like "1536", "512KB" or "1.5 GB"
+func ParseByteSize(s string) (ByteSize, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	unit := ByteSize(1)
+	for _, u := range byteUnits {
+		if strings.HasSuffix(value, u.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, u.suffix))
+			unit = u.size
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return ByteSize(n * float64(unit)), nil
+}
+
+// UnmarshalJSON accepts a number of bytes or a string with a unit
+func (b *ByteSize) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		size, err := ParseByteSize(s)
+		if err != nil {
+			return err
+		}
+		*b = size
+		return nil
+	}
+	var n int64
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("invalid size %s", data)
+	}
+	*b = ByteSize(n)
+	return nil
+}
+
+// String renders the size with the largest fitting unit, e.g. "10 MB"
+func (b ByteSize) String() string {
+	for _, u := range byteUnits {
+		if b >= u.size && u.size > 1 {
+			return strconv.FormatFloat(float64(b)/float64(u.size), 'f', -1, 64) + " " + u.suffix
+		}
+	}
+	return strconv.FormatInt(int64(b), 10) + " B"
+}
+
+// AllowsMimeType reports whether files of the MIME type are accepted. Every
+// type is accepted if no allowed_mime_types are set.
+func (f Field) AllowsMimeType(mimeType string) bool {
+	if len(f.Validation.AllowedMimeTypes) == 0 {
+		return true
+	}
+	mimeType = strings.ToLower(strings.TrimSpace(strings.Split(mimeType, ";")[0]))
+	for _, allowed := range f.Validation.AllowedMimeTypes {
+		allowed = strings.ToLower(allowed)
+		if allowed == mimeType || allowed == "*/*" {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(mimeType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsExtension reports whether one of the extensions is accepted. Every
+// extension is accepted if no allowed_extensions are set.
+func (f Field) AllowsExtension(exts ...string) bool {
+	if len(f.Validation.AllowedExtensions) == 0 {
+		return true
+	}
+	for _, ext := range exts {
+		for _, allowed := range f.Validation.AllowedExtensions {
+			if strings.EqualFold(normalizeExtension(allowed), normalizeExtension(ext)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func normalizeExtension(ext string) string {
+	return "." + strings.TrimPrefix(strings.TrimSpace(ext), ".")
+}
+
+// validateFile checks the upload constraints of a field
+func validateFile(field Field) []error {
+	v := field.Validation
+	hasConstraints := v.MaxFileSize != 0 || len(v.AllowedMimeTypes) > 0 || len(v.AllowedExtensions) > 0 || v.MinFiles != 0 || v.MaxFiles != 0
+	if field.Type != FileType {
+		if hasConstraints {
+			return []error{fmt.Errorf("field '%s' has file constraints but is not of type 'file'", field.Name)}
+		}
+		return nil
+	}
+
+	var errs []error
+	if v.MaxFileSize < 0 {
+		errs = append(errs, fmt.Errorf("file field '%s' has a negative max_file_size", field.Name))
+	}
+	if v.MinFiles < 0 || v.MaxFiles < 0 {
+		errs = append(errs, fmt.Errorf("file field '%s' has negative min_files/max_files", field.Name))
+	}
+	if v.MaxFiles > 0 && v.MinFiles > v.MaxFiles {
+		errs = append(errs, fmt.Errorf("file field '%s' has min_files greater than max_files", field.Name))
+	}
+	for _, mimeType := range v.AllowedMimeTypes {
+		if parts := strings.Split(mimeType, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			errs = append(errs, fmt.Errorf("file field '%s' has an invalid MIME type '%s'", field.Name, mimeType))
+		}
+	}
+	for _, ext := range v.AllowedExtensions {
+		if strings.Trim(ext, ". ") == "" {
+			errs = append(errs, fmt.Errorf("file field '%s' has an empty extension", field.Name))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,73 @@
+package form
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestByteSize(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    ByteSize
+		text    string
+		wantErr bool
+	}{
+		{`1536`, 1536, "1.5 KB", false},
+		{`"10MB"`, 10 << 20, "10 MB", false},
+		{`"512 kb"`, 512 << 10, "512 KB", false},
+		{`"1.5GB"`, 3 << 29, "1.5 GB", false},
+		{`"100"`, 100, "100 B", false},
+		{`"ten MB"`, 0, "", true},
+		{`"-1MB"`, 0, "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			var got ByteSize
+			err := json.Unmarshal([]byte(test.input), &got)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %d", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != test.want || got.String() != test.text {
+				t.Errorf("expected %d (%s), got %d (%s)", test.want, test.text, got, got)
+			}
+		})
+	}
+}
+
+func TestFileAllows(t *testing.T) {
+	field := Field{Name: "f", Type: FileType, Validation: Validation{
+		AllowedMimeTypes:  []string{"image/*", "application/pdf"},
+		AllowedExtensions: []string{"pdf", ".PNG", ".jpg"},
+	}}
+
+	mimeTypes := map[string]bool{
+		"image/png":                      true,
+		"application/pdf":                true,
+		"application/pdf; charset=utf-8": true,
+		"application/x-msdownload":       false,
+		"":                               false,
+	}
+	for mimeType, want := range mimeTypes {
+		if got := field.AllowsMimeType(mimeType); got != want {
+			t.Errorf("AllowsMimeType(%q): expected %v, got %v", mimeType, want, got)
+		}
+	}
+
+	exts := map[string]bool{".pdf": true, ".png": true, "JPG": true, ".exe": false}
+	for ext, want := range exts {
+		if got := field.AllowsExtension(ext); got != want {
+			t.Errorf("AllowsExtension(%q): expected %v, got %v", ext, want, got)
+		}
+	}
+
+	if !(Field{Type: FileType}).AllowsMimeType("application/x-msdownload") {
+		t.Error("expected every type to be allowed without allowed_mime_types")
+	}
+}
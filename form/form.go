@@ -8,6 +8,7 @@ import (
 	"reflect"
 	"regexp"
 	"strings"
+	"time"
 )
 
 type Button struct {
@@ -30,21 +31,58 @@ type Field struct {
 	Buttons      []Button   `json:"buttons"`
 	Options      []string   `json:"options,omitempty"`
 	UserValue    string     `json:"user_value"`
+	Skipped      bool       `json:"-"` // The user skipped the field; set on the fields of a session
 	Validation   Validation `json:"validation,omitempty"`
+	ShowIf       *Condition `json:"show_if,omitempty"`  // Field is only asked when the condition holds
+	Next         []NextRule `json:"next,omitempty"`     // First matching rule picks the following field
+	Fields       []Field    `json:"fields,omitempty"`   // Sub-fields of a repeatable "group" field
+	Calendar     bool       `json:"calendar,omitempty"` // Offer an inline calendar for "date" fields
+}
+
+// GroupType is the field type of repeatable field groups
+const GroupType = "group"
+
+// GroupItems decodes the value of a "group" field into one map per item
+func (f Field) GroupItems() ([]map[string]string, error) {
+	if f.UserValue == "" {
+		return nil, nil
+	}
+	var items []map[string]string
+	if err := json.Unmarshal([]byte(f.UserValue), &items); err != nil {
+		return nil, fmt.Errorf("failed to decode items of group '%s': %w", f.Name, err)
+	}
+	return items, nil
 }
 
 type Validation struct {
-	MinLength int    `json:"min_length,omitempty"`
-	MaxLength int    `json:"max_length,omitempty"`
-	Regex     string `json:"regex,omitempty"`
-	Min       int    `json:"min,omitempty"`
-	Max       int    `json:"max,omitempty"`
+	MinLength int      `json:"min_length,omitempty"`
+	MaxLength int      `json:"max_length,omitempty"`
+	Regex     string   `json:"regex,omitempty"`
+	Min       int      `json:"min,omitempty"`
+	Max       int      `json:"max,omitempty"`
+	MinItems  int      `json:"min_items,omitempty"` // Minimum repetitions of a group or selections of a multiselect
+	MaxItems  int      `json:"max_items,omitempty"` // Maximum repetitions of a group or selections of a multiselect (0 = unlimited)
+	MinDate   string   `json:"min_date,omitempty"`  // Earliest date/time, ISO-8601 or relative like "today+1d"
+	MaxDate   string   `json:"max_date,omitempty"`  // Latest date/time, ISO-8601 or relative like "today+30d"
+	Layouts   []string `json:"layouts,omitempty"`   // Accepted input layouts (Go reference time), replacing the defaults
+
+	// Constraints of "file" fields
+	MaxFileSize       ByteSize `json:"max_file_size,omitempty"`      // Largest accepted file, in bytes or e.g. "10MB"
+	AllowedMimeTypes  []string `json:"allowed_mime_types,omitempty"` // Accepted MIME types; "image/*" accepts all images
+	AllowedExtensions []string `json:"allowed_extensions,omitempty"` // Accepted file name extensions, e.g. ".pdf"
+	MinFiles          int      `json:"min_files,omitempty"`          // Minimum number of files
+	MaxFiles          int      `json:"max_files,omitempty"`          // Maximum number of files (0 = unlimited)
 }
 
 type Form struct {
 	FormName      string `json:"form_name"`
+	Slug          string `json:"slug"`              // Picks the form in "/start <slug>"; defaults to the file name
+	Version       string `json:"version,omitempty"` // Version of the format file, sent with webhook events
 	TableName     string `json:"table_name"`
 	ReviewEnabled bool   `json:"review_enabled"`
+	BackButton    bool   `json:"back_button"`           // Show a back button on every step; /back works regardless
+	WebhookURL    string `json:"webhook_url,omitempty"` // Overrides the webhook URL from the config for this form
+	Timezone      string `json:"timezone,omitempty"`    // IANA time zone for dates without one and "today"; defaults to the server's
 	//SubmitMessage string  `json:"submit_message"`
 	Messages Message `json:"messages"`
 	Fields   []Field `json:"fields"`
@@ -52,71 +90,152 @@ type Form struct {
 }
 
 type Message struct {
-	Submit              string `json:"submit"`
-	SubmitButton        string `json:"submit_button"`
-	SkipButton          string `json:"skip_button"`
-	Modify              string `json:"modify"`
-	ModifyButton        string `json:"modify_button"`
-	ChooseOption        string `json:"choose_option"`
-	Review              string `json:"review"`
-	FileUploadSuccess   string `json:"file_upload_success"`
-	UploadAnother       string `json:"upload_another"`
-	UploadAnotherButton string `json:"upload_another_button"`
-	FinishUploadButton  string `json:"finish_upload_button"`
-	FinishUpload        string `json:"finish_upload"`
-	RequiredFile        string `json:"required_file"`
-	RequiredSelect      string `json:"required_select"`
-	RequiredInput       string `json:"required_input"`
-	InvalidEmail        string `json:"invalid_email"`
-	InvalidMaxNumber    string `json:"invalid_max_number"`
-	InvalidMinNumber    string `json:"invalid_min_number"`
-	InvalidNumber       string `json:"invalid_number"`
-	InvalidFormat       string `json:"invalid_format"`
-	ValidationError     string `json:"validation_error"`
-	InvalidMaxLength    string `json:"invalid_max_length"`
-	InvalidMinLength    string `json:"invalid_min_length"`
+	Submit                  string `json:"submit"`
+	SubmitButton            string `json:"submit_button"`
+	SkipButton              string `json:"skip_button"`
+	Modify                  string `json:"modify"`
+	ModifyButton            string `json:"modify_button"`
+	ChooseOption            string `json:"choose_option"`
+	Review                  string `json:"review"`
+	FileUploadSuccess       string `json:"file_upload_success"`
+	UploadAnother           string `json:"upload_another"`
+	UploadAnotherButton     string `json:"upload_another_button"`
+	FinishUploadButton      string `json:"finish_upload_button"`
+	FinishUpload            string `json:"finish_upload"`
+	RequiredFile            string `json:"required_file"`
+	RequiredSelect          string `json:"required_select"`
+	RequiredInput           string `json:"required_input"`
+	InvalidEmail            string `json:"invalid_email"`
+	InvalidMaxNumber        string `json:"invalid_max_number"`
+	InvalidMinNumber        string `json:"invalid_min_number"`
+	InvalidNumber           string `json:"invalid_number"`
+	InvalidFormat           string `json:"invalid_format"`
+	ValidationError         string `json:"validation_error"`
+	InvalidMaxLength        string `json:"invalid_max_length"`
+	InvalidMinLength        string `json:"invalid_min_length"`
+	AddItemButton           string `json:"add_item_button"`
+	AddAnother              string `json:"add_another"`
+	FinishGroupButton       string `json:"finish_group_button"`
+	RequiredItems           string `json:"required_items"`
+	BackButton              string `json:"back_button"`
+	KeepButton              string `json:"keep_button"`
+	CurrentAnswer           string `json:"current_answer"`
+	NoPreviousStep          string `json:"no_previous_step"`
+	InvalidDate             string `json:"invalid_date"`
+	InvalidTime             string `json:"invalid_time"`
+	InvalidDateTime         string `json:"invalid_datetime"`
+	DateTooEarly            string `json:"date_too_early"`
+	DateTooLate             string `json:"date_too_late"`
+	PickDate                string `json:"pick_date"`
+	SharePhoneButton        string `json:"share_phone_button"`
+	ShareContactButton      string `json:"share_contact_button"`
+	ShareLocationButton     string `json:"share_location_button"`
+	UseKeyboardButton       string `json:"use_keyboard_button"`
+	InvalidPhone            string `json:"invalid_phone"`
+	InputReceived           string `json:"input_received"`
+	SelectDoneButton        string `json:"select_done_button"`
+	ChooseOptions           string `json:"choose_options"`
+	RequiredSelections      string `json:"required_selections"`
+	TooManySelections       string `json:"too_many_selections"`
+	FileUploadFailed        string `json:"file_upload_failed"`
+	FileTooLarge            string `json:"file_too_large"`
+	FileTypeNotAllowed      string `json:"file_type_not_allowed"`
+	FileExtensionNotAllowed string `json:"file_extension_not_allowed"`
+	TooFewFiles             string `json:"too_few_files"`
+	TooManyFiles            string `json:"too_many_files"`
+	AlbumUploadSuccess      string `json:"album_upload_success"`
 }
 
 const (
-	Submit              string = "🎉 Hooray! Your form has been submitted successfully! 🎊"
-	SubmitButton        string = "✅ Send it in!"
-	SkipButton          string = "⏭️ Skip for now"
-	Modify              string = "📝 Please enter a new value for %s:"
-	ModifyButton        string = "✏️ Change %s"
-	ChooseOption        string = "👇 Pick one of the options:"
-	Review              string = "🔎 <b>Let's Review Your Inputs:</b>"
-	FileUploadSuccess   string = "📂 Your file has been uploaded successfully!"
-	UploadAnotherButton string = "📁 Upload another file"
-	UploadAnother       string = "Need to upload another? Go ahead!"
-	FinishUploadButton  string = "✔️ Done uploading"
-	FinishUpload        string = "📤 Would you like to upload more files or finish?"
-	RequiredFile        string = "⚠️ A file is needed for %s. Please upload one!"
-	RequiredSelect      string = "⚠️ You must make a selection! Choose one for %s from: %s."
-	RequiredInput       string = "⚠️ This %s is mandatory. Please enter a value."
-	InvalidEmail        string = "🚨 That doesn’t look like a valid email. Try again!"
-	InvalidMaxNumber    string = "⚠️ The value for %s must be at most %d. Please enter a valid number."
-	InvalidMinNumber    string = "⚠️ The value for %s must be at least %d. Please enter a valid number."
-	InvalidNumber       string = "⚠️ Oops! %s needs to be a number."
-	InvalidFormat       string = "⚠️ The format for %s is incorrect. Please check and fix it."
-	ValidationError     string = "⚠️ Something went wrong with %s. Try again!"
-	InvalidMaxLength    string = "⚠️ %s is too long! Maximum %d characters allowed."
-	InvalidMinLength    string = "⚠️ %s is too short! Minimum %d characters required."
+	Submit                  string = "🎉 Hooray! Your form has been submitted successfully! 🎊"
+	SubmitButton            string = "✅ Send it in!"
+	SkipButton              string = "⏭️ Skip for now"
+	Modify                  string = "📝 Please enter a new value for %s:"
+	ModifyButton            string = "✏️ Change %s"
+	ChooseOption            string = "👇 Pick one of the options:"
+	Review                  string = "🔎 <b>Let's Review Your Inputs:</b>"
+	FileUploadSuccess       string = "📂 Your file has been uploaded successfully!"
+	UploadAnotherButton     string = "📁 Upload another file"
+	UploadAnother           string = "Need to upload another? Go ahead!"
+	FinishUploadButton      string = "✔️ Done uploading"
+	FinishUpload            string = "📤 Would you like to upload more files or finish?"
+	RequiredFile            string = "⚠️ A file is needed for %s. Please upload one!"
+	RequiredSelect          string = "⚠️ You must make a selection! Choose one for %s from: %s."
+	RequiredInput           string = "⚠️ This %s is mandatory. Please enter a value."
+	InvalidEmail            string = "🚨 That doesn’t look like a valid email. Try again!"
+	InvalidMaxNumber        string = "⚠️ The value for %s must be at most %d. Please enter a valid number."
+	InvalidMinNumber        string = "⚠️ The value for %s must be at least %d. Please enter a valid number."
+	InvalidNumber           string = "⚠️ Oops! %s needs to be a number."
+	InvalidFormat           string = "⚠️ The format for %s is incorrect. Please check and fix it."
+	ValidationError         string = "⚠️ Something went wrong with %s. Try again!"
+	InvalidMaxLength        string = "⚠️ %s is too long! Maximum %d characters allowed."
+	InvalidMinLength        string = "⚠️ %s is too short! Minimum %d characters required."
+	AddItemButton           string = "➕ Add %s"
+	AddAnother              string = "➕ Would you like to add another %s?"
+	FinishGroupButton       string = "✔️ That's all"
+	RequiredItems           string = "⚠️ Please add at least %d %s before continuing."
+	BackButton              string = "⬅️ Back"
+	KeepButton              string = "✅ Keep current answer"
+	CurrentAnswer           string = "✏️ Current answer: %s"
+	NoPreviousStep          string = "⚠️ This is the first question, there is nothing to go back to."
+	InvalidDate             string = "⚠️ %s is not a valid date. Try something like 2025-12-31."
+	InvalidTime             string = "⚠️ %s is not a valid time. Try something like 14:30."
+	InvalidDateTime         string = "⚠️ %s is not a valid date and time. Try something like 2025-12-31 14:30."
+	DateTooEarly            string = "⚠️ %s must not be before %s."
+	DateTooLate             string = "⚠️ %s must not be after %s."
+	PickDate                string = "📅 Pick a date or type it:"
+	SharePhoneButton        string = "📱 Share my phone number"
+	ShareContactButton      string = "👤 Share a contact"
+	ShareLocationButton     string = "📍 Share my location"
+	UseKeyboardButton       string = "⚠️ Please use the button below or attach a %s."
+	InvalidPhone            string = "⚠️ %s doesn't look like a phone number. Please include the country code, e.g. +14155550123."
+	InputReceived           string = "👍 Got it!"
+	SelectDoneButton        string = "✔️ Done"
+	ChooseOptions           string = "👇 Pick all that apply, then press Done:"
+	RequiredSelections      string = "⚠️ Please choose at least %d option(s) for %s."
+	TooManySelections       string = "⚠️ You can choose at most %d option(s) for %s."
+	FileUploadFailed        string = "⚠️ Sorry, your file could not be saved. Please send it again."
+	FileTooLarge            string = "⚠️ This file is too large for %s. Files can be at most %s."
+	FileTypeNotAllowed      string = "⚠️ This type of file is not accepted for %s. Allowed types: %s."
+	FileExtensionNotAllowed string = "⚠️ Files for %s must end in one of: %s."
+	TooFewFiles             string = "⚠️ Please upload at least %d file(s) for %s."
+	TooManyFiles            string = "⚠️ You can upload at most %d file(s) for %s."
+	AlbumUploadSuccess      string = "✅ %d files uploaded successfully!"
 )
 
 // Expected format placeholders for each message key
 var expectedPlaceholders = map[string]int{
-	"Modify":           1, // Requires 1 %s
-	"ModifyButton":     1, // Requires 1 %s
-	"RequiredFile":     1, // Requires 1 %s
-	"RequiredSelect":   2, // Requires 2 (%s, %s)
-	"RequiredInput":    1, // Requires 1 %s
-	"InvalidMaxNumber": 2, // Requires 1 %s and 1 %d
-	"InvalidMinNumber": 2, // Requires 1 %s and 1 %d
-	"InvalidNumber":    1, // Requires 1 %s
-	"InvalidFormat":    1, // Requires 1 %s
-	"ValidationError":  1, // Requires 1 %s
-	"InvalidMaxLength": 2, // Requires 1 %s and 1 %d
-	"InvalidMinLength": 2, // Requires 1 %s and 1 %d
+	"Modify":                  1, // Requires 1 %s
+	"ModifyButton":            1, // Requires 1 %s
+	"RequiredFile":            1, // Requires 1 %s
+	"RequiredSelect":          2, // Requires 2 (%s, %s)
+	"RequiredInput":           1, // Requires 1 %s
+	"InvalidMaxNumber":        2, // Requires 1 %s and 1 %d
+	"InvalidMinNumber":        2, // Requires 1 %s and 1 %d
+	"InvalidNumber":           1, // Requires 1 %s
+	"InvalidFormat":           1, // Requires 1 %s
+	"ValidationError":         1, // Requires 1 %s
+	"InvalidMaxLength":        2, // Requires 1 %s and 1 %d
+	"InvalidMinLength":        2, // Requires 1 %s and 1 %d
+	"AddItemButton":           1, // Requires 1 %s
+	"AddAnother":              1, // Requires 1 %s
+	"RequiredItems":           2, // Requires 1 %d and 1 %s
+	"CurrentAnswer":           1, // Requires 1 %s
+	"InvalidDate":             1, // Requires 1 %s
+	"InvalidTime":             1, // Requires 1 %s
+	"InvalidDateTime":         1, // Requires 1 %s
+	"DateTooEarly":            2, // Requires 2 (%s, %s)
+	"DateTooLate":             2, // Requires 2 (%s, %s)
+	"UseKeyboardButton":       1, // Requires 1 %s
+	"InvalidPhone":            1, // Requires 1 %s
+	"RequiredSelections":      2, // Requires 1 %d and 1 %s
+	"TooManySelections":       2, // Requires 1 %d and 1 %s
+	"FileTooLarge":            2, // Requires 2 (%s, %s)
+	"FileTypeNotAllowed":      2, // Requires 2 (%s, %s)
+	"FileExtensionNotAllowed": 2, // Requires 2 (%s, %s)
+	"TooFewFiles":             2, // Requires 1 %d and 1 %s
+	"TooManyFiles":            2, // Requires 1 %d and 1 %s
+	"AlbumUploadSuccess":      1, // Requires 1 %d
 }
 
 func LoadTicketFormat(path string) (*Form, error) {
@@ -130,6 +249,9 @@ func LoadTicketFormat(path string) (*Form, error) {
 		return nil, fmt.Errorf("failed to unmarshal ticket.json: %w", err)
 	}
 
+	if tf.Slug == "" {
+		tf.Slug = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
 	tf.DefaultMessages()
 
 	return &tf, nil
@@ -192,6 +314,69 @@ func validateField(field Field) []error {
 		}
 	}
 
+	// 6. Date and time fields need valid bounds
+	if field.IsTemporal() {
+		errs = append(errs, validateTemporal(field)...)
+	} else if field.Calendar {
+		errs = append(errs, fmt.Errorf("field '%s' can only show a calendar if it is of type 'date'", field.Name))
+	}
+
+	// 7. Locations and contacts are stored as JSON documents
+	if field.Type == LocationType || field.Type == ContactType {
+		errs = append(errs, validateJSONColumn(field)...)
+	}
+
+	// 8. Multi-selects need options and sane selection bounds
+	if field.Type == MultiselectType {
+		errs = append(errs, validateMultiselect(field)...)
+	}
+
+	// 9. Upload constraints only apply to file fields and must be sane
+	errs = append(errs, validateFile(field)...)
+
+	// 10. Groups need sub-fields, sane repetition bounds and a column that can hold an array
+	if field.Type == GroupType {
+		errs = append(errs, validateGroup(field)...)
+	} else if len(field.Fields) > 0 {
+		errs = append(errs, fmt.Errorf("field '%s' has sub-fields but is not of type 'group'", field.Name))
+	}
+
+	return errs
+}
+
+// validateJSONColumn checks that the column of a field with a JSON value can hold it
+func validateJSONColumn(field Field) []error {
+	if field.DBType != "" && !contains([]string{"JSON", "JSONB", "TEXT", "OBJECT"}, strings.ToUpper(field.DBType)) {
+		return []error{fmt.Errorf("%s field '%s' must use a JSON, TEXT or OBJECT db_type", field.Type, field.Name)}
+	}
+	return nil
+}
+
+// validateGroup checks the settings specific to "group" fields
+func validateGroup(field Field) []error {
+	var errs []error
+
+	if len(field.Fields) == 0 {
+		errs = append(errs, fmt.Errorf("group field '%s' must have fields", field.Name))
+	}
+	if field.Validation.MinItems < 0 || field.Validation.MaxItems < 0 {
+		errs = append(errs, fmt.Errorf("group field '%s' has negative item limits", field.Name))
+	}
+	if field.Validation.MaxItems > 0 && field.Validation.MinItems > field.Validation.MaxItems {
+		errs = append(errs, fmt.Errorf("group field '%s' has invalid min/max item constraints", field.Name))
+	}
+	errs = append(errs, validateJSONColumn(field)...)
+
+	for _, sub := range field.Fields {
+		if sub.Type == GroupType {
+			errs = append(errs, fmt.Errorf("group field '%s' cannot contain another group '%s'", field.Name, sub.Name))
+			continue
+		}
+		if sub.ShowIf != nil || len(sub.Next) > 0 {
+			errs = append(errs, fmt.Errorf("conditional flow is not supported inside group field '%s'", field.Name))
+		}
+		errs = append(errs, validateField(sub)...)
+	}
 	return errs
 }
 
@@ -207,6 +392,14 @@ func (f *Form) ValidateForm() ([]error, []string) {
 	if f.TableName == "" {
 		errs = append(errs, fmt.Errorf("table_name cannot be empty"))
 	}
+	if !slugRegex.MatchString(f.Slug) {
+		errs = append(errs, fmt.Errorf("slug '%s' must be 1-64 letters, digits, underscores or dashes", f.Slug))
+	}
+	if f.Timezone != "" {
+		if _, err := time.LoadLocation(f.Timezone); err != nil {
+			errs = append(errs, fmt.Errorf("timezone '%s' is unknown: %v", f.Timezone, err))
+		}
+	}
 
 	// 2. Ensure ReviewEnabled is a bool (automatic in Go)
 	if f.ReviewEnabled != true && f.ReviewEnabled != false {
@@ -219,7 +412,10 @@ func (f *Form) ValidateForm() ([]error, []string) {
 		errs = append(errs, fieldErrors...)
 	}
 
-	// 4. DB validation (if applicable)
+	// 4. Validate conditional flow (show_if / next)
+	errs = append(errs, f.validateFlow()...)
+
+	// 5. DB validation (if applicable)
 	if f.DB != "" {
 		var err error
 		for i, field := range f.Fields {
@@ -245,6 +441,8 @@ var dbTypeMapping = map[string]map[string]string{
 		"NUMBER":   "INT",
 		"BOOLEAN":  "BOOLEAN",
 		"DATETIME": "DATETIME",
+		"DATE":     "DATE",
+		"TIME":     "TIME",
 		"JSON":     "JSON",
 	},
 	"postgres": {
@@ -253,6 +451,8 @@ var dbTypeMapping = map[string]map[string]string{
 		"NUMBER":   "INTEGER",
 		"BOOLEAN":  "BOOLEAN",
 		"DATETIME": "TIMESTAMP",
+		"DATE":     "DATE",
+		"TIME":     "TIME",
 		"JSON":     "JSONB",
 	},
 	"mongo": {
@@ -261,6 +461,8 @@ var dbTypeMapping = map[string]map[string]string{
 		"NUMBER":   "int",
 		"BOOLEAN":  "bool",
 		"DATETIME": "date",
+		"DATE":     "date",
+		"TIME":     "string",
 		"OBJECT":   "object",
 	},
 	"sqlite": {
@@ -269,7 +471,9 @@ var dbTypeMapping = map[string]map[string]string{
 		"NUMBER":   "INTEGER", // Or "NUMERIC" if you want to store both integers and floats, but INTEGER is common for "NUMBER" type mapping
 		"BOOLEAN":  "INTEGER", // SQLite doesn't have a dedicated BOOLEAN type, INTEGER with 0 and 1 is common practice
 		"DATETIME": "TEXT",    // SQLite best practice for DATETIME is to store as TEXT in ISO8601 format, or INTEGER as Unix Time
-		"JSON":     "TEXT",    // SQLite stores JSON as TEXT using JSON1 extension (you need to ensure JSON1 extension is enabled in your SQLite build if you intend to use JSON functions)
+		"DATE":     "TEXT",
+		"TIME":     "TEXT",
+		"JSON":     "TEXT", // SQLite stores JSON as TEXT using JSON1 extension (you need to ensure JSON1 extension is enabled in your SQLite build if you intend to use JSON functions)
 	},
 }
 
@@ -433,4 +637,97 @@ func (f *Form) DefaultMessages() {
 	if f.Messages.InvalidMinLength == "" {
 		f.Messages.InvalidMinLength = InvalidMinLength
 	}
+	if f.Messages.AddItemButton == "" {
+		f.Messages.AddItemButton = AddItemButton
+	}
+	if f.Messages.AddAnother == "" {
+		f.Messages.AddAnother = AddAnother
+	}
+	if f.Messages.FinishGroupButton == "" {
+		f.Messages.FinishGroupButton = FinishGroupButton
+	}
+	if f.Messages.RequiredItems == "" {
+		f.Messages.RequiredItems = RequiredItems
+	}
+	if f.Messages.BackButton == "" {
+		f.Messages.BackButton = BackButton
+	}
+	if f.Messages.KeepButton == "" {
+		f.Messages.KeepButton = KeepButton
+	}
+	if f.Messages.CurrentAnswer == "" {
+		f.Messages.CurrentAnswer = CurrentAnswer
+	}
+	if f.Messages.NoPreviousStep == "" {
+		f.Messages.NoPreviousStep = NoPreviousStep
+	}
+	if f.Messages.InvalidDate == "" {
+		f.Messages.InvalidDate = InvalidDate
+	}
+	if f.Messages.InvalidTime == "" {
+		f.Messages.InvalidTime = InvalidTime
+	}
+	if f.Messages.InvalidDateTime == "" {
+		f.Messages.InvalidDateTime = InvalidDateTime
+	}
+	if f.Messages.DateTooEarly == "" {
+		f.Messages.DateTooEarly = DateTooEarly
+	}
+	if f.Messages.DateTooLate == "" {
+		f.Messages.DateTooLate = DateTooLate
+	}
+	if f.Messages.PickDate == "" {
+		f.Messages.PickDate = PickDate
+	}
+	if f.Messages.SharePhoneButton == "" {
+		f.Messages.SharePhoneButton = SharePhoneButton
+	}
+	if f.Messages.ShareContactButton == "" {
+		f.Messages.ShareContactButton = ShareContactButton
+	}
+	if f.Messages.ShareLocationButton == "" {
+		f.Messages.ShareLocationButton = ShareLocationButton
+	}
+	if f.Messages.UseKeyboardButton == "" {
+		f.Messages.UseKeyboardButton = UseKeyboardButton
+	}
+	if f.Messages.InvalidPhone == "" {
+		f.Messages.InvalidPhone = InvalidPhone
+	}
+	if f.Messages.InputReceived == "" {
+		f.Messages.InputReceived = InputReceived
+	}
+	if f.Messages.SelectDoneButton == "" {
+		f.Messages.SelectDoneButton = SelectDoneButton
+	}
+	if f.Messages.ChooseOptions == "" {
+		f.Messages.ChooseOptions = ChooseOptions
+	}
+	if f.Messages.RequiredSelections == "" {
+		f.Messages.RequiredSelections = RequiredSelections
+	}
+	if f.Messages.TooManySelections == "" {
+		f.Messages.TooManySelections = TooManySelections
+	}
+	if f.Messages.FileUploadFailed == "" {
+		f.Messages.FileUploadFailed = FileUploadFailed
+	}
+	if f.Messages.FileTooLarge == "" {
+		f.Messages.FileTooLarge = FileTooLarge
+	}
+	if f.Messages.FileTypeNotAllowed == "" {
+		f.Messages.FileTypeNotAllowed = FileTypeNotAllowed
+	}
+	if f.Messages.FileExtensionNotAllowed == "" {
+		f.Messages.FileExtensionNotAllowed = FileExtensionNotAllowed
+	}
+	if f.Messages.TooFewFiles == "" {
+		f.Messages.TooFewFiles = TooFewFiles
+	}
+	if f.Messages.TooManyFiles == "" {
+		f.Messages.TooManyFiles = TooManyFiles
+	}
+	if f.Messages.AlbumUploadSuccess == "" {
+		f.Messages.AlbumUploadSuccess = AlbumUploadSuccess
+	}
 }
@@ -0,0 +1,62 @@
+package form
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// slugRegex matches the characters Telegram allows in a /start deep link payload
+var slugRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// LoadForms loads the forms from the given paths. A path is either a format
+// file or a directory, in which case every *.json file in it is loaded.
+func LoadForms(paths []string) ([]*Form, error) {
+	var forms []*Form
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		files := []string{path}
+		if info.IsDir() {
+			files, err = filepath.Glob(filepath.Join(path, "*.json"))
+			if err != nil {
+				return nil, fmt.Errorf("failed to list %s: %w", path, err)
+			}
+			if len(files) == 0 {
+				return nil, fmt.Errorf("no format files found in %s", path)
+			}
+			sort.Strings(files)
+		}
+
+		for _, file := range files {
+			f, err := LoadTicketFormat(file)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+			forms = append(forms, f)
+		}
+	}
+
+	if len(forms) == 0 {
+		return nil, fmt.Errorf("no format files given")
+	}
+	return forms, nil
+}
+
+// ValidateForms checks what has to be unique across the forms of one bot
+func ValidateForms(forms []*Form) []error {
+	var errs []error
+	slugs := make(map[string]bool)
+	for _, f := range forms {
+		if slugs[f.Slug] {
+			errs = append(errs, fmt.Errorf("slug '%s' is used by more than one form", f.Slug))
+		}
+		slugs[f.Slug] = true
+	}
+	return errs
+}
@@ -0,0 +1,45 @@
+package form
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadForms(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	write("billing.json", `{"form_name": "Billing", "table_name": "billing"}`)
+	write("support.json", `{"form_name": "Support", "slug": "help", "table_name": "support"}`)
+	write("notes.txt", `not a form`)
+
+	forms, err := LoadForms([]string{dir})
+	if err != nil {
+		t.Fatalf("LoadForms: %v", err)
+	}
+	if len(forms) != 2 || forms[0].Slug != "billing" || forms[1].Slug != "help" {
+		t.Fatalf("unexpected forms %+v", forms)
+	}
+	if errs := ValidateForms(forms); len(errs) != 0 {
+		t.Errorf("unexpected errors %v", errs)
+	}
+
+	// The same file twice gives a duplicate slug
+	again, err := LoadForms([]string{dir, filepath.Join(dir, "billing.json")})
+	if err != nil {
+		t.Fatalf("LoadForms: %v", err)
+	}
+	if errs := ValidateForms(again); len(errs) != 1 {
+		t.Errorf("got %v, want one duplicate slug error", errs)
+	}
+
+	if _, err := LoadForms([]string{t.TempDir()}); err == nil {
+		t.Error("expected an error for a directory without forms")
+	}
+}
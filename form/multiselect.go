@@ -0,0 +1,48 @@
+package form
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// MultiselectType is the field type that lets users pick several options
+const MultiselectType = "multiselect"
+
+// Selections decodes the value of a "multiselect" field
+func (f Field) Selections() ([]string, error) {
+	if f.IsNull() {
+		return nil, nil
+	}
+	var selected []string
+	if err := json.Unmarshal([]byte(f.UserValue), &selected); err != nil {
+		return nil, fmt.Errorf("failed to decode selections of field '%s': %w", f.Name, err)
+	}
+	return selected, nil
+}
+
+// validateMultiselect checks the settings specific to "multiselect" fields
+func validateMultiselect(field Field) []error {
+	var errs []error
+
+	if len(field.Options) == 0 {
+		errs = append(errs, fmt.Errorf("multiselect field '%s' must have options", field.Name))
+	}
+	seen := make(map[string]bool)
+	for _, option := range field.Options {
+		if seen[option] {
+			errs = append(errs, fmt.Errorf("multiselect field '%s' has the option '%s' more than once", field.Name, option))
+		}
+		seen[option] = true
+	}
+	if field.Validation.MinItems < 0 || field.Validation.MaxItems < 0 {
+		errs = append(errs, fmt.Errorf("multiselect field '%s' has negative selection limits", field.Name))
+	}
+	if field.Validation.MaxItems > 0 && field.Validation.MinItems > field.Validation.MaxItems {
+		errs = append(errs, fmt.Errorf("multiselect field '%s' has invalid min/max item constraints", field.Name))
+	}
+	if field.Validation.MinItems > len(field.Options) {
+		errs = append(errs, fmt.Errorf("multiselect field '%s' requires more selections than it has options", field.Name))
+	}
+	errs = append(errs, validateJSONColumn(field)...)
+	return errs
+}
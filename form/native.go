@@ -0,0 +1,100 @@
+package form
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Field types filled from Telegram's own sharing features
+const (
+	PhoneType    = "phone"    // Phone number, shared as contact or typed
+	LocationType = "location" // Shared location or venue
+	ContactType  = "contact"  // Shared contact
+)
+
+// Location is the value of a "location" field. Title and Address are set
+// when a venue was shared.
+type Location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Title     string  `json:"title,omitempty"`
+	Address   string  `json:"address,omitempty"`
+}
+
+// Contact is the value of a "contact" field
+type Contact struct {
+	PhoneNumber string `json:"phone_number"`
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name,omitempty"`
+	UserID      int64  `json:"user_id,omitempty"` // Telegram user of the contact, if known
+}
+
+// phoneRegex matches phone numbers typed with common separators
+var phoneRegex = regexp.MustCompile(`^\+?[0-9][0-9 ().-]{5,24}$`)
+
+// NormalizePhone strips separators from a phone number and keeps a leading
+// "+". It returns false if text does not look like a phone number.
+func NormalizePhone(text string) (string, bool) {
+	text = strings.TrimSpace(text)
+	if !phoneRegex.MatchString(text) {
+		return "", false
+	}
+	var b strings.Builder
+	if strings.HasPrefix(text, "+") {
+		b.WriteByte('+')
+	}
+	for _, r := range text {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	digits := strings.TrimPrefix(b.String(), "+")
+	if len(digits) < 6 || len(digits) > 15 {
+		return "", false
+	}
+	return b.String(), true
+}
+
+// UsesReplyKeyboard reports whether the field is answered with a reply
+// keyboard button that shares the user's contact or location
+func (f Field) UsesReplyKeyboard() bool {
+	return f.Type == PhoneType || f.Type == LocationType || f.Type == ContactType
+}
+
+// IsJSON reports whether the value of the field is a JSON document
+func (f Field) IsJSON() bool {
+	return f.Type == GroupType || f.Type == LocationType || f.Type == ContactType || f.Type == MultiselectType || f.Type == FileType
+}
+
+// JSONValue decodes the value of a field for which IsJSON is true, so it can
+// be stored or sent as structured data. Empty and skipped values are nil.
+func (f Field) JSONValue() (interface{}, error) {
+	if f.IsNull() {
+		return nil, nil
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(f.UserValue), &v); err != nil {
+		return nil, fmt.Errorf("failed to decode value of field '%s': %w", f.Name, err)
+	}
+	return v, nil
+}
+
+// LocationValue decodes the value of a "location" field
+func (f Field) LocationValue() (Location, error) {
+	var l Location
+	if err := json.Unmarshal([]byte(f.UserValue), &l); err != nil {
+		return l, fmt.Errorf("failed to decode location of field '%s': %w", f.Name, err)
+	}
+	return l, nil
+}
+
+// ContactValue decodes the value of a "contact" field
+func (f Field) ContactValue() (Contact, error) {
+	var c Contact
+	if err := json.Unmarshal([]byte(f.UserValue), &c); err != nil {
+		return c, fmt.Errorf("failed to decode contact of field '%s': %w", f.Name, err)
+	}
+	return c, nil
+}
@@ -0,0 +1,191 @@
+package form
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kinds of values a db_type column holds
+const (
+	stringKind = iota
+	intKind
+	floatKind
+	boolKind
+	dateKind
+	dateTimeKind
+	jsonKind
+)
+
+// columnKinds maps db_type names, both the generic ones and the database
+// specific ones, to the kind of value they hold. Unknown types are strings.
+var columnKinds = map[string]int{
+	"NUMBER":           intKind,
+	"INT":              intKind,
+	"INTEGER":          intKind,
+	"SMALLINT":         intKind,
+	"TINYINT":          intKind,
+	"MEDIUMINT":        intKind,
+	"BIGINT":           intKind,
+	"LONG":             intKind,
+	"REAL":             floatKind,
+	"FLOAT":            floatKind,
+	"DOUBLE":           floatKind,
+	"DOUBLE PRECISION": floatKind,
+	"DECIMAL":          floatKind,
+	"NUMERIC":          floatKind,
+	"BOOLEAN":          boolKind,
+	"BOOL":             boolKind,
+	"DATE":             dateKind,
+	"DATETIME":         dateTimeKind,
+	"TIMESTAMP":        dateTimeKind,
+	"TIMESTAMPTZ":      dateTimeKind,
+	"JSON":             jsonKind,
+	"JSONB":            jsonKind,
+	"OBJECT":           jsonKind,
+	"ARRAY":            jsonKind,
+}
+
+// Layouts accepted for DATE and DATETIME columns when the field itself is not
+// a date, time or datetime field
+var columnTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	DateLayout,
+}
+
+// columnKind returns the kind of the column of the field. The db_type from
+// the format file wins, the mapped database type is the fallback.
+func (f Field) columnKind() int {
+	for _, dbType := range []string{f.DBType, f.ActualDBType} {
+		// Drop sizes such as VARCHAR(255) or DECIMAL(10,2)
+		if i := strings.Index(dbType, "("); i >= 0 {
+			dbType = dbType[:i]
+		}
+		if kind, ok := columnKinds[strings.ToUpper(strings.TrimSpace(dbType))]; ok {
+			return kind
+		}
+	}
+	return stringKind
+}
+
+// IsNull reports whether the field has no answer to store: it was skipped,
+// hidden by show_if or never asked
+func (f Field) IsNull() bool {
+	return f.UserValue == "" || f.Skipped
+}
+
+// Value converts the validated answer into the Go value for the db_type of
+// the field: int64 or float64 for numbers, bool for booleans, time.Time for
+// DATE and DATETIME columns, decoded JSON for JSON documents and string
+// otherwise. Fields without an answer are nil, which is stored as NULL.
+func (f Field) Value() (interface{}, error) {
+	if f.IsNull() {
+		return nil, nil
+	}
+
+	value := strings.TrimSpace(f.UserValue)
+	switch f.columnKind() {
+	case intKind:
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n, nil
+		}
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, f.conversionError("a number", err)
+		}
+		return n, nil
+	case floatKind:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, f.conversionError("a number", err)
+		}
+		return n, nil
+	case boolKind:
+		b, err := parseBool(value)
+		if err != nil {
+			return nil, f.conversionError("a boolean", err)
+		}
+		return b, nil
+	case dateKind:
+		t, err := f.columnTime(value)
+		if err != nil {
+			return nil, f.conversionError("a date", err)
+		}
+		// Keep the calendar day, whatever time zone the driver converts to
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+	case dateTimeKind:
+		t, err := f.columnTime(value)
+		if err != nil {
+			return nil, f.conversionError("a datetime", err)
+		}
+		return t, nil
+	case jsonKind:
+		if f.IsJSON() {
+			return f.JSONValue()
+		}
+		// Plain answers in a JSON column are stored as JSON strings
+		return f.UserValue, nil
+	}
+
+	// Groups, locations and contacts in TEXT columns stay encoded
+	return f.UserValue, nil
+}
+
+// SQLValue is Value in a form database/sql drivers accept: JSON documents are
+// bound as encoded text instead of maps and slices
+func (f Field) SQLValue() (interface{}, error) {
+	v, err := f.Value()
+	if err != nil || v == nil {
+		return v, err
+	}
+	if f.columnKind() != jsonKind {
+		return v, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, f.conversionError("JSON", err)
+	}
+	return string(data), nil
+}
+
+// IsDateColumn reports whether the field is stored in a DATE column
+func (f Field) IsDateColumn() bool {
+	return f.columnKind() == dateKind
+}
+
+// columnTime parses the answer for a DATE or DATETIME column. Answers of date
+// and datetime fields are already normalized to ISO-8601.
+func (f Field) columnTime(value string) (time.Time, error) {
+	if f.IsTemporal() && f.Type != TimeType {
+		return f.ParseTemporal(value, time.UTC)
+	}
+	var err error
+	for _, layout := range columnTimeLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+func (f Field) conversionError(kind string, err error) error {
+	return fmt.Errorf("cannot store value %q of field '%s' as %s: %w", f.UserValue, f.Name, kind, err)
+}
+
+// parseBool accepts the values of strconv.ParseBool and yes/no answers
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "yes", "y", "on":
+		return true, nil
+	case "no", "n", "off":
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
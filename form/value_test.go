@@ -0,0 +1,60 @@
+package form
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFieldValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   Field
+		want    interface{}
+		wantSQL interface{}
+		wantErr bool
+	}{
+		{"Skipped", Field{Type: "number", DBType: "NUMBER", Skipped: true}, nil, nil, false},
+		{"Text reading skipped", Field{Type: "text", DBType: "STRING", UserValue: "skipped"}, "skipped", "skipped", false},
+		{"Not answered", Field{Type: "text", DBType: "STRING", UserValue: ""}, nil, nil, false},
+		{"Text", Field{Type: "text", DBType: "STRING", UserValue: "hello"}, "hello", "hello", false},
+		{"Integer", Field{Type: "number", DBType: "NUMBER", UserValue: "42"}, int64(42), int64(42), false},
+		{"Mapped integer", Field{Type: "number", ActualDBType: "INTEGER", UserValue: "7"}, int64(7), int64(7), false},
+		{"Decimal", Field{Type: "text", DBType: "DECIMAL(10,2)", UserValue: "12.50"}, 12.5, 12.5, false},
+		{"Invalid number", Field{Type: "text", DBType: "NUMBER", UserValue: "many"}, nil, nil, true},
+		{"Boolean", Field{Type: "select", DBType: "BOOLEAN", UserValue: "Yes"}, true, true, false},
+		{"Invalid boolean", Field{Type: "text", DBType: "BOOLEAN", UserValue: "maybe"}, nil, nil, true},
+		{"Date", Field{Type: DateType, DBType: "DATE", UserValue: "2025-12-31"}, time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC), time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC), false},
+		{"Datetime", Field{Type: DateTimeType, DBType: "DATETIME", UserValue: "2025-12-31T14:30:00Z"}, time.Date(2025, 12, 31, 14, 30, 0, 0, time.UTC), time.Date(2025, 12, 31, 14, 30, 0, 0, time.UTC), false},
+		{"Time", Field{Type: TimeType, DBType: "TIME", UserValue: "14:30:00"}, "14:30:00", "14:30:00", false},
+		{"Multiselect", Field{Type: MultiselectType, DBType: "JSON", UserValue: `["a","b"]`}, []interface{}{"a", "b"}, `["a","b"]`, false},
+		{"Text in JSON column", Field{Type: "text", DBType: "JSON", UserValue: "hi"}, "hi", `"hi"`, false},
+		{"Group in TEXT column", Field{Type: GroupType, DBType: "TEXT", UserValue: `[{"a":"1"}]`}, `[{"a":"1"}]`, `[{"a":"1"}]`, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.field.Value()
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("expected %#v, got %#v", test.want, got)
+			}
+
+			gotSQL, err := test.field.SQLValue()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(gotSQL, test.wantSQL) {
+				t.Errorf("expected SQL value %#v, got %#v", test.wantSQL, gotSQL)
+			}
+		})
+	}
+}
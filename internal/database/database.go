@@ -3,17 +3,26 @@ package database
 import (
 	"fmt"
 	"go-tg-support-ticket/form"
+	"go-tg-support-ticket/session"
 	"net/url"
 	"strings"
 )
 
+// SessionTable is the table (or collection) in-progress sessions are stored in
+const SessionTable = "bot_sessions"
+
 type Adaptor interface {
 	Open(dns string) error
+	Close() error
 	GetName() string
 
 	Migrate(schema *form.Form) error
 
-	InsertUserInputs(tableName string, fields []form.Field) error
+	InsertUserInputs(id, tableName string, fields []form.Field) error // id is the submission ID, a UUID
+
+	SaveSession(s *session.Session) error
+	DeleteSession(chatID int64) error
+	LoadSessions() ([]*session.Session, error)
 }
 
 type Config struct {
@@ -5,10 +5,13 @@ package mongo
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/google/uuid"
 	"go-tg-support-ticket/form"
+	"go-tg-support-ticket/internal/database"
 	"go-tg-support-ticket/internal/store"
+	"go-tg-support-ticket/session"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -21,8 +24,9 @@ func init() {
 }
 
 type adaptor struct {
-	client *mongo.Client
-	coll   *mongo.Collection
+	client   *mongo.Client
+	coll     *mongo.Collection
+	sessions *mongo.Collection
 }
 
 func (a *adaptor) Open(dns string) error {
@@ -36,10 +40,21 @@ func (a *adaptor) Open(dns string) error {
 	}
 	a.client = client
 	a.coll = client.Database("test").Collection("supports")
+	a.sessions = client.Database("test").Collection(database.SessionTable)
 
 	return nil
 }
 
+// Close disconnects the client
+func (a *adaptor) Close() error {
+	if a.client == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return a.client.Disconnect(ctx)
+}
+
 func (a *adaptor) GetName() string {
 	return "mongo"
 }
@@ -48,16 +63,25 @@ func (a *adaptor) Migrate(_ *form.Form) error {
 	return nil
 }
 
-func (a *adaptor) InsertUserInputs(_ string, fields []form.Field) error {
+func (a *adaptor) InsertUserInputs(id, _ string, fields []form.Field) error {
 
-	// Generate a UUID v7 for the _id field
-	id, _ := uuid.NewV7()
+	// The submission ID becomes the _id field
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid submission ID: %w", err)
+	}
 
 	// Build the MongoDB document
-	doc := bson.M{"_id": id}
+	doc := bson.M{"_id": uid}
 	for _, field := range fields {
 		if field.DBType != "" {
-			doc[field.Name] = field.UserValue
+			// Numbers, booleans and dates keep their BSON types and
+			// JSON documents are embedded
+			value, err := field.Value()
+			if err != nil {
+				return err
+			}
+			doc[field.Name] = value
 		}
 	}
 
@@ -65,10 +89,68 @@ func (a *adaptor) InsertUserInputs(_ string, fields []form.Field) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	_, err := a.coll.InsertOne(ctx, doc)
+	_, err = a.coll.InsertOne(ctx, doc)
 	if err != nil {
 		return fmt.Errorf("failed to add into database: %w", err)
 	}
 
 	return nil
 }
+
+// SaveSession inserts or replaces the stored session of the chat.
+func (a *adaptor) SaveSession(s *session.Session) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": s.ChatID}
+	doc := bson.M{"_id": s.ChatID, "data": string(data)}
+	_, err = a.sessions.ReplaceOne(ctx, filter, doc, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	return nil
+}
+
+// DeleteSession removes the stored session of the chat.
+func (a *adaptor) DeleteSession(chatID int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := a.sessions.DeleteOne(ctx, bson.M{"_id": chatID}); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+// LoadSessions returns every stored session.
+func (a *adaptor) LoadSessions() ([]*session.Session, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := a.sessions.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sessions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var sessions []*session.Session
+	for cursor.Next(ctx) {
+		var doc struct {
+			Data string `bson:"data"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("failed to decode session: %w", err)
+		}
+		s, err := session.Unmarshal([]byte(doc.Data))
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, cursor.Err()
+}
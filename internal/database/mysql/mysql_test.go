@@ -5,6 +5,9 @@ import (
 	"testing"
 )
 
+// testSubmissionID is the ID the inserted rows get
+const testSubmissionID = "0190a3f4-5b6c-7d8e-9f00-112233445566"
+
 func TestBuildInsertQuery(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -15,36 +18,46 @@ func TestBuildInsertQuery(t *testing.T) {
 		expectError    bool
 	}{
 		{
-			name:      "All fields have values",
+			name:      "All fields have db_type",
 			tableName: "survey_responses",
 			fields: []form.Field{
-				{Name: "name", UserValue: "John Doe"},
-				{Name: "email", UserValue: "john.doe@example.com"},
-				{Name: "age", UserValue: "30"},
+				{Name: "name", ActualDBType: "TEXT", UserValue: "John Doe"},
+				{Name: "email", ActualDBType: "VARCHAR(255)", UserValue: "john.doe@example.com"},
+				{Name: "age", ActualDBType: "INTEGER", UserValue: "30"},
 			},
-			expectedQuery:  "INSERT INTO survey_responses (name, email, age) VALUES (?, ?, ?)",
-			expectedValues: []interface{}{"John Doe", "john.doe@example.com", "30"},
+			expectedQuery:  "INSERT INTO survey_responses (id, name, email, age) VALUES (?, ?, ?, ?)",
+			expectedValues: []interface{}{testSubmissionID, "John Doe", "john.doe@example.com", int64(30)},
 			expectError:    false,
 		},
 		{
 			name:      "Some fields have no values",
 			tableName: "survey_responses",
 			fields: []form.Field{
-				{Name: "name", UserValue: "Jane Doe"},
-				{Name: "email", UserValue: ""}, // Empty value
-				{Name: "age", UserValue: "25"},
+				{Name: "name", ActualDBType: "TEXT", UserValue: "Jane Doe"},
+				{Name: "email", ActualDBType: "VARCHAR(255)", UserValue: ""}, // Stored as NULL
+				{Name: "age", ActualDBType: "INTEGER", UserValue: "25"},
 			},
-			expectedQuery:  "INSERT INTO survey_responses (name, age) VALUES (?, ?)",
-			expectedValues: []interface{}{"Jane Doe", "25"},
+			expectedQuery:  "INSERT INTO survey_responses (id, name, email, age) VALUES (?, ?, ?, ?)",
+			expectedValues: []interface{}{testSubmissionID, "Jane Doe", nil, int64(25)},
 			expectError:    false,
 		},
 		{
-			name:      "No fields have values",
+			name:      "Some fields missing db_type",
 			tableName: "survey_responses",
 			fields: []form.Field{
-				{Name: "name", UserValue: ""},
-				{Name: "email", UserValue: ""},
-				{Name: "age", UserValue: ""},
+				{Name: "name", ActualDBType: "TEXT", UserValue: "Jane Doe"},
+				{Name: "email", UserValue: "jane.doe@example.com"}, // Ignored
+			},
+			expectedQuery:  "INSERT INTO survey_responses (id, name) VALUES (?, ?)",
+			expectedValues: []interface{}{testSubmissionID, "Jane Doe"},
+			expectError:    false,
+		},
+		{
+			name:      "No fields with db_type",
+			tableName: "survey_responses",
+			fields: []form.Field{
+				{Name: "name", UserValue: "John Doe"},
+				{Name: "email", UserValue: "john.doe@example.com"},
 			},
 			expectedQuery:  "",
 			expectedValues: nil,
@@ -54,27 +67,27 @@ func TestBuildInsertQuery(t *testing.T) {
 			name:      "Empty table name",
 			tableName: "",
 			fields: []form.Field{
-				{Name: "name", UserValue: "John Doe"},
+				{Name: "name", ActualDBType: "TEXT", UserValue: "John Doe"},
 			},
 			expectedQuery:  "",
 			expectedValues: nil,
 			expectError:    true,
 		},
 		{
-			name:      "Single field with value",
+			name:      "Single field with db_type",
 			tableName: "survey_responses",
 			fields: []form.Field{
-				{Name: "name", UserValue: "John Doe"},
+				{Name: "name", ActualDBType: "TEXT", UserValue: "John Doe"},
 			},
-			expectedQuery:  "INSERT INTO survey_responses (name) VALUES (?)",
-			expectedValues: []interface{}{"John Doe"},
+			expectedQuery:  "INSERT INTO survey_responses (id, name) VALUES (?, ?)",
+			expectedValues: []interface{}{testSubmissionID, "John Doe"},
 			expectError:    false,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			query, values, err := buildInsertQuery(tt.tableName, tt.fields)
+			query, values, err := buildInsertQuery(testSubmissionID, tt.tableName, tt.fields)
 
 			if tt.expectError {
 				if err == nil {
@@ -0,0 +1,76 @@
+package mysql
+
+import (
+	"fmt"
+	"go-tg-support-ticket/form"
+	"strings"
+)
+
+// BuildCreateTableQuery generates a CREATE TABLE SQL statement dynamically
+func buildCreateTableQuery(schema *form.Form) (string, error) {
+	if schema.TableName == "" {
+		return "", fmt.Errorf("table name cannot be empty")
+	}
+
+	// Ensure at least one field has `db_type`
+	var columns []string
+	for _, field := range schema.Fields {
+		if field.Name != "" && field.ActualDBType != "" {
+			column := fmt.Sprintf("%s %s", field.Name, field.ActualDBType)
+			if field.Required {
+				column += " NOT NULL"
+			}
+			columns = append(columns, column)
+		}
+	}
+
+	// Ensure we have valid columns
+	if len(columns) == 0 {
+		return "", fmt.Errorf("no valid fields with db_type found")
+	}
+
+	// Add primary key column
+	columns = append([]string{"id VARCHAR(36) PRIMARY KEY"}, columns...)
+
+	// Build the final SQL query
+	query := fmt.Sprintf("CREATE TABLE %s (%s);", schema.TableName, strings.Join(columns, ", "))
+
+	return query, nil
+}
+
+// buildInsertQuery generates an INSERT query for the given submission ID,
+// table and fields. It returns the query and the corresponding values.
+func buildInsertQuery(id, tableName string, fields []form.Field) (string, []interface{}, error) {
+	if tableName == "" {
+		return "", nil, fmt.Errorf("table name is empty")
+	}
+
+	var columns []string
+	var values []interface{}
+
+	for _, field := range fields {
+		if field.ActualDBType != "" { // Only include fields with user input
+			columns = append(columns, strings.ToLower(field.Name))
+			value, err := field.SQLValue()
+			if err != nil {
+				return "", nil, err
+			}
+			values = append(values, value)
+		}
+	}
+
+	if len(columns) == 0 {
+		return "", nil, fmt.Errorf("no user inputs to insert")
+	}
+	columns = append([]string{"id"}, columns...)
+	values = append([]interface{}{id}, values...)
+
+	query := fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES (%s)",
+		tableName,
+		strings.Join(columns, ", "),
+		strings.Repeat("?, ", len(columns)-1)+"?",
+	)
+
+	return query, values, nil
+}
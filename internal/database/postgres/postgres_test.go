@@ -20,9 +20,9 @@ func TestBuildCreateTableQuery(t *testing.T) {
 			schema: form.Form{
 				TableName: "users",
 				Fields: []form.Field{
-					{Name: "name", DBType: "TEXT", Required: true},
-					{Name: "email", DBType: "VARCHAR(255)", Required: false},
-					{Name: "age", DBType: "INTEGER", Required: true},
+					{Name: "name", ActualDBType: "TEXT", Required: true},
+					{Name: "email", ActualDBType: "VARCHAR(255)", Required: false},
+					{Name: "age", ActualDBType: "INTEGER", Required: true},
 				},
 			},
 			expectedQuery: `CREATE TABLE users ("id" UUID PRIMARY KEY DEFAULT gen_random_uuid(), "name" TEXT NOT NULL, "email" VARCHAR(255), "age" INTEGER NOT NULL);`,
@@ -33,7 +33,7 @@ func TestBuildCreateTableQuery(t *testing.T) {
 			schema: form.Form{
 				TableName: "",
 				Fields: []form.Field{
-					{Name: "name", DBType: "TEXT"},
+					{Name: "name", ActualDBType: "TEXT"},
 				},
 			},
 			expectedQuery: "",
@@ -53,8 +53,8 @@ func TestBuildCreateTableQuery(t *testing.T) {
 			schema: form.Form{
 				TableName: "partial_fields",
 				Fields: []form.Field{
-					{Name: "valid_field", DBType: "TEXT"},
-					{Name: "invalid_field", DBType: ""},
+					{Name: "valid_field", ActualDBType: "TEXT"},
+					{Name: "invalid_field", ActualDBType: ""},
 				},
 			},
 			expectedQuery: `CREATE TABLE partial_fields ("id" UUID PRIMARY KEY DEFAULT gen_random_uuid(), "valid_field" TEXT);`,
@@ -65,8 +65,8 @@ func TestBuildCreateTableQuery(t *testing.T) {
 			schema: form.Form{
 				TableName: "case_test",
 				Fields: []form.Field{
-					{Name: "FullName", DBType: "TEXT", Required: true},
-					{Name: "EMAIL", DBType: "VARCHAR(255)", Required: false},
+					{Name: "FullName", ActualDBType: "TEXT", Required: true},
+					{Name: "EMAIL", ActualDBType: "VARCHAR(255)", Required: false},
 				},
 			},
 			expectedQuery: `CREATE TABLE case_test ("id" UUID PRIMARY KEY DEFAULT gen_random_uuid(), "fullname" TEXT NOT NULL, "email" VARCHAR(255));`,
@@ -77,7 +77,7 @@ func TestBuildCreateTableQuery(t *testing.T) {
 			schema: form.Form{
 				TableName: "only_pk",
 				Fields: []form.Field{
-					{Name: "no_db_type_field", DBType: ""},
+					{Name: "no_db_type_field", ActualDBType: ""},
 				},
 			},
 			expectedQuery: "",
@@ -106,6 +106,9 @@ func TestBuildCreateTableQuery(t *testing.T) {
 	}
 }
 
+// testSubmissionID is the ID the inserted rows get
+const testSubmissionID = "0190a3f4-5b6c-7d8e-9f00-112233445566"
+
 func TestBuildInsertQuery(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -119,32 +122,32 @@ func TestBuildInsertQuery(t *testing.T) {
 			name:      "All fields have db_type",
 			tableName: "survey_responses",
 			fields: []form.Field{
-				{Name: "name", DBType: "TEXT", UserValue: "John Doe"},
-				{Name: "email", DBType: "TEXT", UserValue: "john.doe@example.com"},
-				{Name: "age", DBType: "INTEGER", UserValue: "30"},
+				{Name: "name", ActualDBType: "TEXT", UserValue: "John Doe"},
+				{Name: "email", ActualDBType: "TEXT", UserValue: "john.doe@example.com"},
+				{Name: "age", ActualDBType: "INTEGER", UserValue: "30"},
 			},
-			expectedQuery:  "INSERT INTO survey_responses (name, email, age) VALUES ($1, $2, $3)",
-			expectedValues: []interface{}{"John Doe", "john.doe@example.com", "30"},
+			expectedQuery:  "INSERT INTO survey_responses (id, name, email, age) VALUES ($1, $2, $3, $4)",
+			expectedValues: []interface{}{testSubmissionID, "John Doe", "john.doe@example.com", int64(30)},
 			expectError:    false,
 		},
 		{
 			name:      "Some fields missing db_type",
 			tableName: "survey_responses",
 			fields: []form.Field{
-				{Name: "name", DBType: "TEXT", UserValue: "Jane Doe"},
-				{Name: "email", DBType: "", UserValue: "jane.doe@example.com"}, // Ignored
-				{Name: "age", DBType: "INTEGER", UserValue: "25"},
+				{Name: "name", ActualDBType: "TEXT", UserValue: "Jane Doe"},
+				{Name: "email", ActualDBType: "", UserValue: "jane.doe@example.com"}, // Ignored
+				{Name: "age", ActualDBType: "INTEGER", UserValue: "25"},
 			},
-			expectedQuery:  "INSERT INTO survey_responses (name, age) VALUES ($1, $2)",
-			expectedValues: []interface{}{"Jane Doe", "25"},
+			expectedQuery:  "INSERT INTO survey_responses (id, name, age) VALUES ($1, $2, $3)",
+			expectedValues: []interface{}{testSubmissionID, "Jane Doe", int64(25)},
 			expectError:    false,
 		},
 		{
 			name:      "No fields with db_type",
 			tableName: "survey_responses",
 			fields: []form.Field{
-				{Name: "name", DBType: "", UserValue: "John Doe"},
-				{Name: "email", DBType: "", UserValue: "john.doe@example.com"},
+				{Name: "name", ActualDBType: "", UserValue: "John Doe"},
+				{Name: "email", ActualDBType: "", UserValue: "john.doe@example.com"},
 			},
 			expectedQuery:  "",
 			expectedValues: nil,
@@ -154,7 +157,7 @@ func TestBuildInsertQuery(t *testing.T) {
 			name:      "Empty table name",
 			tableName: "",
 			fields: []form.Field{
-				{Name: "name", DBType: "TEXT", UserValue: "John Doe"},
+				{Name: "name", ActualDBType: "TEXT", UserValue: "John Doe"},
 			},
 			expectedQuery:  "",
 			expectedValues: nil,
@@ -164,17 +167,17 @@ func TestBuildInsertQuery(t *testing.T) {
 			name:      "Single field with db_type",
 			tableName: "survey_responses",
 			fields: []form.Field{
-				{Name: "name", DBType: "TEXT", UserValue: "John Doe"},
+				{Name: "name", ActualDBType: "TEXT", UserValue: "John Doe"},
 			},
-			expectedQuery:  "INSERT INTO survey_responses (name) VALUES ($1)",
-			expectedValues: []interface{}{"John Doe"},
+			expectedQuery:  "INSERT INTO survey_responses (id, name) VALUES ($1, $2)",
+			expectedValues: []interface{}{testSubmissionID, "John Doe"},
 			expectError:    false,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			query, values, err := buildInsertQuery(tt.tableName, tt.fields)
+			query, values, err := buildInsertQuery(testSubmissionID, tt.tableName, tt.fields)
 
 			if tt.expectError {
 				if err == nil {
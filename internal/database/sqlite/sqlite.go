@@ -5,15 +5,16 @@ package sqlite
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/mattn/go-sqlite3"
 	"go-tg-support-ticket/form"
+	"go-tg-support-ticket/internal/database"
 	"go-tg-support-ticket/internal/store"
+	"go-tg-support-ticket/session"
 	"log"
-	"strings"
 )
 
 func init() {
@@ -34,7 +35,15 @@ func (a *adaptor) Open(dsn string) error {
 	db.SetMaxIdleConns(1)
 
 	a.db = db
-	return nil
+	return a.ensureSessionTable()
+}
+
+// Close closes the connection pool
+func (a *adaptor) Close() error {
+	if a.db == nil {
+		return nil
+	}
+	return a.db.Close()
 }
 
 func (a *adaptor) GetName() string {
@@ -82,86 +91,70 @@ func (a *adaptor) tableExists(tableName string) (bool, error) {
 	return true, nil // Table exists
 }
 
-// BuildCreateTableQuery generates a CREATE TABLE SQL statement dynamically for SQLite
-func buildCreateTableQuery(schema *form.Form) (string, error) {
-	if schema.TableName == "" {
-		return "", fmt.Errorf("table name cannot be empty")
-	}
-
-	// Ensure at least one field has `db_type`
-	var columns []string
-	for _, field := range schema.Fields {
-		if field.Name != "" && field.ActualDBType != "" {
-			column := fmt.Sprintf("%s %s", field.Name, field.ActualDBType)
-			if field.Required {
-				column += " NOT NULL"
-			}
-			columns = append(columns, column)
-		}
+// InsertUserInputs inserts user input values into the SQLite database.
+func (a *adaptor) InsertUserInputs(id, tableName string, fields []form.Field) error {
+	// Build the INSERT query and get the values
+	query, values, err := buildInsertQuery(id, tableName, fields)
+	if err != nil {
+		return fmt.Errorf("failed to build INSERT query: %w", err)
 	}
 
-	// Ensure we have valid columns
-	if len(columns) == 0 {
-		return "", fmt.Errorf("no valid fields with db_type found")
+	// Execute the INSERT query
+	_, err = a.db.Exec(query, values...)
+	if err != nil {
+		return fmt.Errorf("failed to execute INSERT query: %w", err)
 	}
 
-	// Add primary key column (UUID as TEXT for SQLite)
-	columns = append([]string{"id TEXT PRIMARY KEY"}, columns...)
-
-	// Build the final SQL query
-	query := fmt.Sprintf("CREATE TABLE %s (%s);", schema.TableName, strings.Join(columns, ", "))
-
-	return query, nil
+	return nil
 }
 
-// buildInsertQuery generates an INSERT query for the given table and fields.
-// It returns the query and the corresponding values.
-func buildInsertQuery(tableName string, fields []form.Field) (string, []interface{}, error) {
-	if tableName == "" {
-		return "", nil, fmt.Errorf("table name is empty")
+// ensureSessionTable creates the session table if it does not exist yet
+func (a *adaptor) ensureSessionTable() error {
+	query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (chat_id BIGINT PRIMARY KEY, data TEXT NOT NULL)", database.SessionTable)
+	if _, err := a.db.Exec(query); err != nil {
+		return fmt.Errorf("failed to create session table: %w", err)
 	}
+	return nil
+}
 
-	var columns []string
-	var values []interface{}
-
-	uid, _ := uuid.NewV7()
-	columns = append(columns, "id")
-	values = append(values, uid.String())
-
-	for _, field := range fields {
-		if field.ActualDBType != "" {
-			columns = append(columns, strings.ToLower(field.Name))
-			values = append(values, field.UserValue)
-		}
+// SaveSession inserts or replaces the stored session of the chat.
+func (a *adaptor) SaveSession(s *session.Session) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
 	}
 
-	if len(columns) == 0 {
-		return "", nil, fmt.Errorf("no user inputs to insert")
+	query := fmt.Sprintf("INSERT INTO %s (chat_id, data) VALUES (?, ?) ON CONFLICT (chat_id) DO UPDATE SET data = excluded.data", database.SessionTable)
+	if _, err := a.db.Exec(query, s.ChatID, string(data)); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
 	}
-
-	query := fmt.Sprintf(
-		"INSERT INTO %s (%s) VALUES (%s)",
-		tableName,
-		strings.Join(columns, ", "),
-		strings.Repeat("?, ", len(columns)-1)+"?",
-	)
-
-	return query, values, nil
+	return nil
 }
 
-// InsertUserInputs inserts user input values into the SQLite database.
-func (a *adaptor) InsertUserInputs(tableName string, fields []form.Field) error {
-	// Build the INSERT query and get the values
-	query, values, err := buildInsertQuery(tableName, fields)
-	if err != nil {
-		return fmt.Errorf("failed to build INSERT query: %w", err)
+// DeleteSession removes the stored session of the chat.
+func (a *adaptor) DeleteSession(chatID int64) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE chat_id = ?", database.SessionTable)
+	if _, err := a.db.Exec(query, chatID); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
 	}
+	return nil
+}
 
-	// Execute the INSERT query
-	_, err = a.db.Exec(query, values...)
-	if err != nil {
-		return fmt.Errorf("failed to execute INSERT query: %w", err)
+// LoadSessions returns every stored session.
+func (a *adaptor) LoadSessions() ([]*session.Session, error) {
+	var rows []string
+	query := fmt.Sprintf("SELECT data FROM %s", database.SessionTable)
+	if err := a.db.Select(&rows, query); err != nil {
+		return nil, fmt.Errorf("failed to load sessions: %w", err)
 	}
 
-	return nil
+	sessions := make([]*session.Session, 0, len(rows))
+	for _, data := range rows {
+		s, err := session.Unmarshal([]byte(data))
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, nil
 }
@@ -70,6 +70,9 @@ func TestBuildCreateTableQuery(t *testing.T) {
 	}
 }
 
+// testSubmissionID is the ID the inserted rows get
+const testSubmissionID = "0190a3f4-5b6c-7d8e-9f00-112233445566"
+
 func TestBuildInsertQuery(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -107,7 +110,7 @@ func TestBuildInsertQuery(t *testing.T) {
 				{Name: "in_stock", ActualDBType: "BOOLEAN", UserValue: "true"},
 			},
 			wantQuery:  "INSERT INTO products (id, product_name, price, in_stock) VALUES (?, ?, ?, ?)",
-			wantValues: []interface{}{"Laptop", "1200.50", "true"},
+			wantValues: []interface{}{"Laptop", 1200.5, true},
 			shouldFail: false,
 		},
 		{
@@ -118,14 +121,34 @@ func TestBuildInsertQuery(t *testing.T) {
 				{Name: "nickname", ActualDBType: "TEXT", UserValue: ""},
 			},
 			wantQuery:  "INSERT INTO users (id, name, nickname) VALUES (?, ?, ?)",
-			wantValues: []interface{}{"Alice", ""},
+			wantValues: []interface{}{"Alice", nil},
+			shouldFail: false,
+		},
+		{
+			name:      "Insert with Skipped Number and Dates",
+			tableName: "orders",
+			fields: []form.Field{
+				{Name: "quantity", DBType: "NUMBER", ActualDBType: "INTEGER", Skipped: true},
+				{Name: "due", Type: "date", DBType: "DATE", ActualDBType: "TEXT", UserValue: "2025-12-31"},
+				{Name: "at", Type: "datetime", DBType: "DATETIME", ActualDBType: "TEXT", UserValue: "2025-12-31T14:30:00+07:00"},
+			},
+			wantQuery:  "INSERT INTO orders (id, quantity, due, at) VALUES (?, ?, ?, ?)",
+			wantValues: []interface{}{nil, "2025-12-31", "2025-12-31T14:30:00+07:00"},
 			shouldFail: false,
 		},
+		{
+			name:      "Invalid Number",
+			tableName: "orders",
+			fields: []form.Field{
+				{Name: "quantity", DBType: "NUMBER", ActualDBType: "INTEGER", UserValue: "many"},
+			},
+			shouldFail: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotQuery, gotValues, err := buildInsertQuery(tt.tableName, tt.fields)
+			gotQuery, gotValues, err := buildInsertQuery(testSubmissionID, tt.tableName, tt.fields)
 			if (err != nil) != tt.shouldFail {
 				t.Fatalf("Expected error: %v, got: %v", tt.shouldFail, err)
 			}
@@ -136,9 +159,12 @@ func TestBuildInsertQuery(t *testing.T) {
 					t.Errorf("Expected query:\n%s\ngot:\n%s", tt.wantQuery, gotQuery)
 				}
 
-				// Check the values (skip the first value, which is the UUID)
+				// Check the values (the first value is the submission ID)
 				if len(gotValues) != len(tt.wantValues)+1 {
-					t.Errorf("Expected %d values, got %d", len(tt.wantValues)+1, len(gotValues))
+					t.Fatalf("Expected %d values, got %d", len(tt.wantValues)+1, len(gotValues))
+				}
+				if gotValues[0] != testSubmissionID {
+					t.Errorf("Expected the submission ID as id, got: %v", gotValues[0])
 				}
 
 				// Compare the values (skip the first value, which is the UUID)
@@ -0,0 +1,34 @@
+package fileutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "data.json")
+
+	for _, content := range []string{"first", "second"} {
+		if err := WriteFile(name, []byte(content), 0o600); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+		data, err := os.ReadFile(name)
+		if err != nil || string(data) != content {
+			t.Fatalf("got %q, %v, want %q", data, err, content)
+		}
+	}
+
+	info, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("got mode %v, want 0600", info.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("got %d entries, want no temporary files left", len(entries))
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"go-tg-support-ticket/form"
 	"go-tg-support-ticket/internal/database"
+	"go-tg-support-ticket/session"
 )
 
 func init() {
 	Store = store{}
 	Tickets = ticketObj{}
+	Sessions = sessionObj{}
 }
 
 var adp database.Adaptor
@@ -18,6 +20,7 @@ var enabled bool
 type PersistenceStorageInterface interface {
 	Open(cfg *database.Config) error
 	Migrate(schema *form.Form) error
+	Close() error
 }
 
 var Store PersistenceStorageInterface
@@ -40,6 +43,14 @@ func (store) Migrate(schema *form.Form) error {
 	return fmt.Errorf("database persistence is not enabled")
 }
 
+// Close closes the database connection of the adaptor
+func (store) Close() error {
+	if enabled {
+		return adp.Close()
+	}
+	return nil
+}
+
 func openAdaptor(cfg *database.Config) error {
 	if ad, ok := availableAdapters[cfg.UseAdaptor]; ok {
 		adp = ad
@@ -67,16 +78,42 @@ func RegisterAdaptor(a database.Adaptor) {
 }
 
 type TicketPersistence interface {
-	Create(tableName string, fields []form.Field) error
+	Create(s *session.Session) error
 }
 
 var Tickets TicketPersistence
 
 type ticketObj struct{}
 
-func (ticketObj) Create(tableName string, fields []form.Field) error {
+func (ticketObj) Create(s *session.Session) error {
 	if enabled {
-		return adp.InsertUserInputs(tableName, fields)
+		return adp.InsertUserInputs(s.ID, s.Form.TableName, s.Fields())
 	}
 	return nil
 }
+
+// Sessions stores in-progress sessions through the configured adaptor
+var Sessions session.Store
+
+type sessionObj struct{}
+
+func (sessionObj) Save(s *session.Session) error {
+	if enabled {
+		return adp.SaveSession(s)
+	}
+	return fmt.Errorf("database persistence is not enabled")
+}
+
+func (sessionObj) Delete(chatID int64) error {
+	if enabled {
+		return adp.DeleteSession(chatID)
+	}
+	return fmt.Errorf("database persistence is not enabled")
+}
+
+func (sessionObj) LoadAll() ([]*session.Session, error) {
+	if enabled {
+		return adp.LoadSessions()
+	}
+	return nil, fmt.Errorf("database persistence is not enabled")
+}
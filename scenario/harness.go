@@ -0,0 +1,210 @@
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"go-tg-support-ticket/bot"
+	"go-tg-support-ticket/bot/bottest"
+	"go-tg-support-ticket/form"
+	"go-tg-support-ticket/internal/store"
+	"go-tg-support-ticket/session"
+	"go-tg-support-ticket/storage"
+	"go-tg-support-ticket/webhook"
+	"os"
+	"sync"
+	"time"
+)
+
+// chatID is the chat of the scripted user
+const chatID = 1
+
+// harness runs the real bot flow against a fake Telegram server. While it
+// runs, submissions, webhook events and uploads go to in-memory recorders
+// and a temporary directory instead of the configured database, webhook and
+// storage, so only one harness may run at a time.
+type harness struct {
+	srv     *bottest.Server
+	tickets *ticketRecorder
+	hooks   *webhookRecorder
+
+	bot     *bot.Bot
+	cancel  context.CancelFunc
+	done    chan error
+	dir     string
+	restore func()
+}
+
+// startHarness starts the bot serving the forms
+func startHarness(forms []*form.Form, clickTimeout time.Duration) (*harness, error) {
+	dir, err := os.MkdirTemp("", "gotgbot-scenario-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload directory: %w", err)
+	}
+	files, err := storage.NewLocal(storage.LocalConfig{Dir: dir})
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to set up upload storage: %w", err)
+	}
+
+	h := &harness{
+		srv:     bottest.NewServer(),
+		tickets: &ticketRecorder{},
+		hooks:   &webhookRecorder{},
+		dir:     dir,
+	}
+	h.srv.ClickTimeout = clickTimeout
+	h.restore = swapGlobals(h.tickets, h.hooks, files)
+
+	api, err := h.srv.API()
+	if err == nil {
+		h.bot, err = bot.NewBotWithAPI(&bot.Config{Token: bottest.Token}, forms, api)
+		if err != nil {
+			err = fmt.Errorf("failed to create bot: %w", err)
+		}
+	}
+	if err != nil {
+		h.srv.Close()
+		h.restore()
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	var ctx context.Context
+	ctx, h.cancel = context.WithCancel(context.Background())
+	h.done = make(chan error, 1)
+	go func() { h.done <- h.bot.Start(ctx) }()
+	return h, nil
+}
+
+// close stops the bot and the fake server and restores the replaced globals
+func (h *harness) close(timeout time.Duration) error {
+	defer os.RemoveAll(h.dir)
+	defer h.restore()
+	defer h.srv.Close()
+
+	h.cancel()
+	if err := <-h.done; err != nil {
+		return fmt.Errorf("bot stopped with error: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := h.bot.Shutdown(ctx); err != nil {
+		return fmt.Errorf("bot shutdown: %w", err)
+	}
+	return nil
+}
+
+// swapGlobals points the ticket store, webhook workers and file storage at
+// the recorders and returns a function restoring the previous ones
+func swapGlobals(tickets store.TicketPersistence, hooks webhook.WorkerInterface, files storage.Backend) func() {
+	prevTickets, prevHooks, prevFiles := store.Tickets, webhook.Workers, storage.Files
+	store.Tickets, webhook.Workers, storage.Files = tickets, hooks, files
+	return func() {
+		store.Tickets, webhook.Workers, storage.Files = prevTickets, prevHooks, prevFiles
+	}
+}
+
+// ticketRecorder keeps the values of the last submission in memory
+type ticketRecorder struct {
+	recorder
+}
+
+func (t *ticketRecorder) Create(s *session.Session) error {
+	values := make(map[string]interface{})
+	for _, field := range s.Fields() {
+		v, err := field.Value()
+		if err != nil {
+			t.record(nil, err)
+			return err
+		}
+		values[field.Name] = v
+	}
+	t.record(values, nil)
+	return nil
+}
+
+// webhookRecorder keeps the data of the last form.submitted event in memory
+type webhookRecorder struct {
+	recorder
+	event webhook.Event // Last event, guarded by recorder.mu
+	sent  bool
+}
+
+func (w *webhookRecorder) Enqueue(s *session.Session) {
+	w.Publish(webhook.NewEvent(s))
+}
+
+// Publish records submissions; the other lifecycle events are not checked
+func (w *webhookRecorder) Publish(event webhook.Event) {
+	if event.Type != webhook.FormSubmitted {
+		return
+	}
+	data, _ := event.Data.(map[string]interface{})
+	w.mu.Lock()
+	w.event, w.sent = event, true
+	w.mu.Unlock()
+	w.record(data, nil)
+}
+
+// last returns the last event if there was one
+func (w *webhookRecorder) last() (webhook.Event, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.event, w.sent
+}
+
+func (w *webhookRecorder) Shutdown(context.Context) error {
+	return nil
+}
+
+// recorder holds the last recorded values and signals their arrival
+type recorder struct {
+	mu     sync.Mutex
+	values map[string]interface{}
+	err    error // Why the values could not be recorded
+	added  chan struct{}
+}
+
+func (r *recorder) record(values map[string]interface{}, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.values, r.err = values, err
+	if r.added == nil {
+		r.added = make(chan struct{})
+	}
+	select {
+	case <-r.added:
+	default:
+		close(r.added)
+	}
+}
+
+// recorded returns the recorded values without waiting
+func (r *recorder) recorded() (map[string]interface{}, bool, error) {
+	return r.wait(0)
+}
+
+// wait returns the recorded values, waiting up to timeout for them
+func (r *recorder) wait(timeout time.Duration) (map[string]interface{}, bool, error) {
+	r.mu.Lock()
+	if r.added == nil {
+		r.added = make(chan struct{})
+	}
+	added := r.added
+	r.mu.Unlock()
+
+	if timeout > 0 {
+		select {
+		case <-added:
+		case <-time.After(timeout):
+		}
+	}
+	select {
+	case <-added:
+	default:
+		return nil, false, nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.values, true, r.err
+}
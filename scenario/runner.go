@@ -0,0 +1,176 @@
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-tg-support-ticket/bot/bottest"
+	"go-tg-support-ticket/form"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// DefaultTimeout is how long a step waits for each expected reply
+const DefaultTimeout = 2 * time.Second
+
+// Result is the outcome of a scenario
+type Result struct {
+	Scenario *Scenario
+	Failures []string
+}
+
+// Passed reports whether all expectations of the scenario were met
+func (r Result) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// Runner plays scenarios against the real bot flow through a fake Telegram
+// server. Scenarios must not run concurrently, see harness.
+type Runner struct {
+	Forms   []*form.Form
+	Timeout time.Duration // Wait for each expected reply; DefaultTimeout if zero
+}
+
+// Run plays the steps of the scenario and checks the replies and the
+// submitted values
+func (r *Runner) Run(sc *Scenario) (res Result) {
+	res = Result{Scenario: sc}
+	fail := func(format string, args ...interface{}) {
+		res.Failures = append(res.Failures, fmt.Sprintf(format, args...))
+	}
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	h, err := startHarness(r.Forms, timeout)
+	if err != nil {
+		fail("%v", err)
+		return res
+	}
+	defer func() {
+		if err := h.close(timeout); err != nil {
+			fail("%v", err)
+		}
+	}()
+	srv := h.srv
+
+	for i, step := range sc.Steps {
+		if err := play(srv, sc, step); err != nil {
+			fail("step %d (%s): %v", i+1, step.Describe(), err)
+			return res
+		}
+		for _, text := range step.Expect {
+			if _, err := srv.WaitForText(chatID, text, timeout); err != nil {
+				fail("step %d (%s): %v", i+1, step.Describe(), err)
+				return res
+			}
+		}
+		if step.Error != "" {
+			if _, err := srv.WaitForText(chatID, step.Error, timeout); err != nil {
+				fail("step %d (%s): expected validation error: %v", i+1, step.Describe(), err)
+				return res
+			}
+		}
+	}
+
+	if sc.Stored != nil {
+		if values, ok, err := h.tickets.wait(timeout); !ok {
+			fail("the form was not stored")
+		} else if err != nil {
+			fail("the form could not be stored: %v", err)
+		} else {
+			for _, msg := range compareValues(sc.Stored, values) {
+				fail("stored %s", msg)
+			}
+		}
+	}
+	if sc.Webhook != nil {
+		if data, ok, _ := h.hooks.wait(timeout); !ok {
+			fail("no webhook event was sent")
+		} else {
+			for _, msg := range compareValues(sc.Webhook, data) {
+				fail("webhook %s", msg)
+			}
+		}
+	}
+	return res
+}
+
+// play sends the input of the step to the bot
+func play(srv *bottest.Server, sc *Scenario, step Step) error {
+	switch {
+	case step.Send != "":
+		srv.SendText(chatID, step.Send)
+	case step.Click != "":
+		err := srv.ClickFunc(chatID, func(b bottest.Button) bool {
+			return b.Data == step.Click || b.Text == step.Click
+		})
+		if err != nil {
+			return fmt.Errorf("no button %q: %w", step.Click, err)
+		}
+	case step.Upload != nil:
+		data, err := step.Upload.data(sc.Path)
+		if err != nil {
+			return err
+		}
+		if step.Upload.Photo {
+			srv.SendPhoto(chatID, data, step.Upload.Album)
+		} else {
+			srv.SendDocument(chatID, step.Upload.Name, step.Upload.MimeType, data)
+		}
+	}
+	return nil
+}
+
+// compareValues checks the expected values against the actual ones and
+// describes each mismatch. Values are compared in their JSON form.
+func compareValues(want, got map[string]interface{}) []string {
+	names := make([]string, 0, len(want))
+	for name := range want {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var mismatches []string
+	for _, name := range names {
+		actual, ok := got[name]
+		if !ok {
+			mismatches = append(mismatches, fmt.Sprintf("field '%s' is missing", name))
+			continue
+		}
+		w, g := normalize(want[name]), normalize(actual)
+		if !reflect.DeepEqual(w, g) {
+			mismatches = append(mismatches, fmt.Sprintf("field '%s' = %s, want %s", name, encode(g), encode(w)))
+		}
+	}
+	return mismatches
+}
+
+// normalize brings YAML and Go values to the same JSON decoded form. Times at
+// midnight UTC are dates.
+func normalize(v interface{}) interface{} {
+	if t, ok := v.(time.Time); ok {
+		if t.Equal(t.Truncate(24*time.Hour)) && t.Location() == time.UTC {
+			return t.Format(form.DateLayout)
+		}
+		return t.Format(time.RFC3339)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return fmt.Sprint(v)
+	}
+	return out
+}
+
+func encode(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,119 @@
+package scenario
+
+import (
+	"go-tg-support-ticket/form"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func loadHelpDesk(t *testing.T) *Runner {
+	t.Helper()
+	forms, err := form.LoadForms([]string{"../examples/help-desk/format.json"})
+	if err != nil {
+		t.Fatalf("failed to load form: %v", err)
+	}
+	return &Runner{Forms: forms}
+}
+
+func TestRunExamples(t *testing.T) {
+	runner := loadHelpDesk(t)
+	scenarios, err := Load([]string{"../examples/help-desk/scenarios"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sc := range scenarios {
+		t.Run(sc.Name, func(t *testing.T) {
+			if res := runner.Run(sc); !res.Passed() {
+				t.Errorf("expected the scenario to pass, got %v", res.Failures)
+			}
+		})
+	}
+}
+
+func TestRunFailures(t *testing.T) {
+	runner := loadHelpDesk(t)
+	runner.Timeout = 300 * time.Millisecond
+
+	tests := []struct {
+		name     string
+		scenario string
+		want     []string
+	}{
+		{
+			name: "Missing reply",
+			scenario: `
+steps:
+  - send: /start
+    expect: What is your name?`,
+			want: []string{`step 1 (send "/start"): waiting for "What is your name?"`},
+		},
+		{
+			name: "Missing validation error",
+			scenario: `
+steps:
+  - send: /start
+  - click: billing
+  - send: ""
+    error: Please enter a value`,
+			want: []string{"step 3", "expected validation error"},
+		},
+		{
+			name: "Missing button",
+			scenario: `
+steps:
+  - send: /start
+  - click: refund`,
+			want: []string{`step 2 (click "refund"): no button "refund"`},
+		},
+		{
+			name: "Not submitted",
+			scenario: `
+steps:
+  - send: /start
+    expect: What is your ticket about?
+stored:
+  ticket_category: billing`,
+			want: []string{"the form was not stored"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "scenario.yaml")
+			if err := os.WriteFile(path, []byte(tt.scenario), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			sc, err := LoadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res := runner.Run(sc)
+			if res.Passed() {
+				t.Fatal("expected the scenario to fail")
+			}
+			got := strings.Join(res.Failures, "\n")
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("expected failures to contain %q, got %q", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestCompareValues(t *testing.T) {
+	want := map[string]interface{}{"age": 30, "tags": []interface{}{"a", "b"}, "note": nil, "name": "Ada", "extra": 1}
+	got := map[string]interface{}{"age": int64(30), "tags": []interface{}{"a", "b"}, "note": nil, "name": "Grace"}
+
+	mismatches := compareValues(want, got)
+	expected := []string{
+		"field 'extra' is missing",
+		`field 'name' = "Grace", want "Ada"`,
+	}
+	if strings.Join(mismatches, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %q, got %q", expected, mismatches)
+	}
+}
@@ -0,0 +1,177 @@
+package scenario
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Scenario is a scripted conversation with the bot and the outcome it must
+// have
+type Scenario struct {
+	Name    string                 `yaml:"name"`
+	Steps   []Step                 `yaml:"steps"`
+	Stored  map[string]interface{} `yaml:"stored"`  // Expected stored values by field name
+	Webhook map[string]interface{} `yaml:"webhook"` // Expected webhook data by field name
+
+	Path string `yaml:"-"` // File the scenario was loaded from
+}
+
+// Step is one input of the user and the replies it must get
+type Step struct {
+	Send   string  `yaml:"send"`   // Text message or command
+	Click  string  `yaml:"click"`  // Text or callback data of an inline button
+	Upload *Upload `yaml:"upload"` // File sent as a document or photo
+
+	Expect Texts  `yaml:"expect"` // Texts the replies must contain, in order
+	Error  string `yaml:"error"`  // Validation error the reply must contain
+}
+
+// Upload is a file sent by the user. The content is either inline or read
+// from a path relative to the scenario file.
+type Upload struct {
+	Name     string `yaml:"name"`
+	MimeType string `yaml:"mime_type"`
+	Content  string `yaml:"content"`
+	Path     string `yaml:"path"`
+	Photo    bool   `yaml:"photo"` // Send as a photo instead of a document
+	Album    string `yaml:"album"` // Media group ID of a photo
+}
+
+// Texts accepts a single text or a list of texts
+type Texts []string
+
+func (t *Texts) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*t = Texts{value.Value}
+		return nil
+	}
+	var texts []string
+	if err := value.Decode(&texts); err != nil {
+		return err
+	}
+	*t = texts
+	return nil
+}
+
+// Describe returns a short description of the input of the step
+func (s Step) Describe() string {
+	switch {
+	case s.Send != "":
+		return fmt.Sprintf("send %q", s.Send)
+	case s.Click != "":
+		return fmt.Sprintf("click %q", s.Click)
+	case s.Upload != nil && s.Upload.Photo:
+		return "upload photo"
+	case s.Upload != nil:
+		return fmt.Sprintf("upload %q", s.Upload.Name)
+	}
+	return "wait"
+}
+
+// Load reads scenarios from YAML files and directories of them
+func Load(paths []string) ([]*Scenario, error) {
+	var scenarios []*Scenario
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read scenario %s: %w", path, err)
+		}
+		if !info.IsDir() {
+			sc, err := LoadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			scenarios = append(scenarios, sc)
+			continue
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read scenario directory %s: %w", path, err)
+		}
+		for _, entry := range entries {
+			ext := strings.ToLower(filepath.Ext(entry.Name()))
+			if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+				continue
+			}
+			sc, err := LoadFile(filepath.Join(path, entry.Name()))
+			if err != nil {
+				return nil, err
+			}
+			scenarios = append(scenarios, sc)
+		}
+	}
+	if len(scenarios) == 0 {
+		return nil, fmt.Errorf("no scenario files found")
+	}
+	return scenarios, nil
+}
+
+// LoadFile reads and checks one scenario file
+func LoadFile(path string) (*Scenario, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario %s: %w", path, err)
+	}
+
+	var sc Scenario
+	if err := yaml.Unmarshal(data, &sc); err != nil {
+		return nil, fmt.Errorf("failed to parse scenario %s: %w", path, err)
+	}
+	sc.Path = path
+	if sc.Name == "" {
+		sc.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if err := sc.validate(); err != nil {
+		return nil, fmt.Errorf("invalid scenario %s: %w", path, err)
+	}
+	return &sc, nil
+}
+
+func (sc *Scenario) validate() error {
+	if len(sc.Steps) == 0 {
+		return fmt.Errorf("no steps")
+	}
+	for i, step := range sc.Steps {
+		var inputs int
+		for _, set := range []bool{step.Send != "", step.Click != "", step.Upload != nil} {
+			if set {
+				inputs++
+			}
+		}
+		if inputs > 1 {
+			return fmt.Errorf("step %d has more than one of send, click and upload", i+1)
+		}
+		if inputs == 0 && len(step.Expect) == 0 && step.Error == "" {
+			return fmt.Errorf("step %d has neither an input nor an expectation", i+1)
+		}
+		if u := step.Upload; u != nil {
+			if u.Content != "" && u.Path != "" {
+				return fmt.Errorf("step %d: upload has both content and path", i+1)
+			}
+			if u.Album != "" && !u.Photo {
+				return fmt.Errorf("step %d: only photos can be sent as an album", i+1)
+			}
+		}
+	}
+	return nil
+}
+
+// data returns the content of the upload
+func (u *Upload) data(scenarioPath string) ([]byte, error) {
+	if u.Path == "" {
+		return []byte(u.Content), nil
+	}
+	path := u.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(scenarioPath), path)
+	}
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload %s: %w", u.Path, err)
+	}
+	return data, nil
+}
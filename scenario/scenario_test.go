@@ -0,0 +1,88 @@
+package scenario
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		scenario string
+		wantErr  string
+		check    func(t *testing.T, sc *Scenario)
+	}{
+		{
+			name: "Expect as text or list",
+			scenario: `
+steps:
+  - send: /start
+    expect: Hello
+  - click: skip
+    expect: [One, Two]`,
+			check: func(t *testing.T, sc *Scenario) {
+				if sc.Name != "scenario" {
+					t.Errorf("expected the file name as name, got %q", sc.Name)
+				}
+				if len(sc.Steps[0].Expect) != 1 || len(sc.Steps[1].Expect) != 2 {
+					t.Errorf("unexpected expectations %v, %v", sc.Steps[0].Expect, sc.Steps[1].Expect)
+				}
+			},
+		},
+		{name: "No steps", scenario: "name: Empty", wantErr: "no steps"},
+		{
+			name:     "Two inputs",
+			scenario: "steps:\n  - send: Hi\n    click: skip",
+			wantErr:  "step 1 has more than one of send, click and upload",
+		},
+		{
+			name:     "Nothing to do",
+			scenario: "steps:\n  - send: Hi\n  - expect: []",
+			wantErr:  "step 2 has neither an input nor an expectation",
+		},
+		{
+			name:     "Document album",
+			scenario: "steps:\n  - upload: {name: a.txt, album: one}",
+			wantErr:  "only photos can be sent as an album",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "scenario.yaml")
+			if err := os.WriteFile(path, []byte(tt.scenario), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			sc, err := LoadFile(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, sc)
+		})
+	}
+}
+
+func TestUploadData(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "log.txt"), []byte("panic"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	scenarioPath := filepath.Join(dir, "scenario.yaml")
+
+	data, err := (&Upload{Path: "log.txt"}).data(scenarioPath)
+	if err != nil || string(data) != "panic" {
+		t.Errorf("expected the file next to the scenario, got %q, %v", data, err)
+	}
+	data, err = (&Upload{Content: "inline"}).data(scenarioPath)
+	if err != nil || string(data) != "inline" {
+		t.Errorf("expected inline content, got %q, %v", data, err)
+	}
+}
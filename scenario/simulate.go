@@ -0,0 +1,190 @@
+package scenario
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"go-tg-support-ticket/bot/bottest"
+	"go-tg-support-ticket/form"
+	"html"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// simulateQuiet is how long the bot must stay silent before the simulator
+// asks for the next input
+const simulateQuiet = 300 * time.Millisecond
+
+const simulateHelp = `Type your answers. Numbers pick the numbered choices, \text sends text as is.
+/file <path> uploads a document, /photo <path> a photo, /quit ends the simulation.
+`
+
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// Simulator runs a form conversation in the terminal. Messages of the bot are
+// printed with their inline buttons as numbered choices and every input line
+// is sent as the answer of the user. Like Runner, it must not run next to
+// another harness.
+type Simulator struct {
+	Forms []*form.Form
+	Start string // First message of the user; "/start" if empty
+	In    io.Reader
+	Out   io.Writer
+}
+
+// Run simulates the conversation until the form is submitted, the input ends
+// or the user quits. On submission it prints the stored values and the
+// webhook payload.
+func (sim *Simulator) Run() (err error) {
+	h, err := startHarness(sim.Forms, DefaultTimeout)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := h.close(DefaultTimeout); err == nil {
+			err = closeErr
+		}
+	}()
+
+	fmt.Fprint(sim.Out, simulateHelp)
+	start := sim.Start
+	if start == "" {
+		start = "/start"
+	}
+	fmt.Fprintf(sim.Out, "> %s\n", start)
+	h.srv.SendText(chatID, start)
+
+	input := bufio.NewScanner(sim.In)
+	var choices []bottest.Button
+	for {
+		if shown := sim.printReplies(h.srv); len(shown) > 0 {
+			choices = shown
+		}
+		if values, ok, storeErr := h.tickets.recorded(); ok {
+			return sim.printPayload(h, values, storeErr)
+		}
+
+		fmt.Fprint(sim.Out, "> ")
+		if !input.Scan() {
+			fmt.Fprintln(sim.Out)
+			return input.Err()
+		}
+		line := strings.TrimSpace(input.Text())
+		if line == "" {
+			continue
+		}
+		if line == "/quit" {
+			return nil
+		}
+		if err := sim.send(h.srv, line, choices); err != nil {
+			fmt.Fprintf(sim.Out, "❗ %v\n", err)
+		}
+	}
+}
+
+// send passes one input line to the bot
+func (sim *Simulator) send(srv *bottest.Server, line string, choices []bottest.Button) error {
+	if text, ok := strings.CutPrefix(line, `\`); ok {
+		srv.SendText(chatID, text)
+		return nil
+	}
+	if n, err := strconv.Atoi(line); err == nil && n >= 1 && n <= len(choices) {
+		choice := choices[n-1]
+		return srv.ClickFunc(chatID, func(b bottest.Button) bool { return b == choice })
+	}
+
+	command, path, _ := strings.Cut(line, " ")
+	if command == "/file" || command == "/photo" {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			return fmt.Errorf("usage: %s <path>", command)
+		}
+		data, err := os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return fmt.Errorf("failed to read upload: %w", err)
+		}
+		if command == "/photo" {
+			srv.SendPhoto(chatID, data, "")
+		} else {
+			srv.SendDocument(chatID, filepath.Base(path), mime.TypeByExtension(filepath.Ext(path)), data)
+		}
+		return nil
+	}
+
+	srv.SendText(chatID, line)
+	return nil
+}
+
+// printReplies prints the messages of the bot until it stays silent and
+// returns the inline buttons among them in the order they were numbered
+func (sim *Simulator) printReplies(srv *bottest.Server) []bottest.Button {
+	var choices []bottest.Button
+	for {
+		m, err := srv.Next(chatID, simulateQuiet)
+		if err != nil {
+			return choices
+		}
+
+		switch {
+		case m.Method == "answerCallbackQuery":
+			if m.Text != "" {
+				fmt.Fprintf(sim.Out, "ℹ️ %s\n", m.Text)
+			}
+			continue
+		case m.Method == "editMessageReplyMarkup":
+			fmt.Fprintln(sim.Out, "🔄 Updated choices:")
+		case m.Method != "sendMessage" && strings.HasPrefix(m.Method, "send"):
+			fmt.Fprintf(sim.Out, "[%s] %s\n", strings.ToLower(strings.TrimPrefix(m.Method, "send")), renderText(m))
+		default:
+			fmt.Fprintln(sim.Out, renderText(m))
+		}
+
+		for _, row := range m.Buttons {
+			for _, button := range row {
+				choices = append(choices, button)
+				fmt.Fprintf(sim.Out, "  [%d] %s\n", len(choices), button.Text)
+			}
+		}
+		for _, row := range m.Keyboard {
+			fmt.Fprintf(sim.Out, "  ⌨️ %s\n", strings.Join(row, " | "))
+		}
+		fmt.Fprintln(sim.Out)
+	}
+}
+
+// printPayload prints what the submission stores and sends to the webhook
+func (sim *Simulator) printPayload(h *harness, values map[string]interface{}, storeErr error) error {
+	if storeErr != nil {
+		return fmt.Errorf("the form could not be stored: %w", storeErr)
+	}
+	data, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode stored values: %w", err)
+	}
+	fmt.Fprintf(sim.Out, "💾 Stored values:\n%s\n\n", data)
+
+	// The webhook event is queued right after the ticket is stored
+	if _, ok, _ := h.hooks.wait(DefaultTimeout); ok {
+		event, _ := h.hooks.last()
+		data, err := json.MarshalIndent(event, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode webhook payload: %w", err)
+		}
+		fmt.Fprintf(sim.Out, "🔗 Webhook payload:\n%s\n", data)
+	}
+	return nil
+}
+
+// renderText returns the text of the message without HTML markup
+func renderText(m bottest.Message) string {
+	if strings.EqualFold(m.Params.Get("parse_mode"), "HTML") {
+		return html.UnescapeString(htmlTag.ReplaceAllString(m.Text, ""))
+	}
+	return m.Text
+}
@@ -0,0 +1,65 @@
+package scenario
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSimulator(t *testing.T) {
+	runner := loadHelpDesk(t)
+
+	tests := []struct {
+		name  string
+		input []string
+		want  []string
+		skip  []string
+	}{
+		{
+			name:  "Submitted",
+			input: []string{"1", "I was charged twice", "INV-1", "Ada", "bad", "ada@example.com", "High", "1", "8"},
+			want: []string{
+				"  [1] 💳 Billing\n  [2] 🛠️ Technical",
+				"🚨 That doesn’t look like a valid email. Try again!",
+				"Ticket Category: billing", // HTML tags are removed
+				"  [8] ✅ Send it in!",
+				"💾 Stored values:",
+				`"invoice_number": "INV-1"`,
+				`"finish": null`,
+				"🔗 Webhook payload:",
+				`"event": "Help Desk Ticket"`,
+			},
+		},
+		{
+			name:  "Quit before submitting",
+			input: []string{"2", "Crash", "/quit"},
+			want:  []string{"Please upload a screenshot of the issue."},
+			skip:  []string{"💾 Stored values:"},
+		},
+		{
+			name:  "Upload of a missing file",
+			input: []string{"2", "Crash", "/file missing.png"},
+			want:  []string{"❗ failed to read upload"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			sim := Simulator{Forms: runner.Forms, In: strings.NewReader(strings.Join(tt.input, "\n") + "\n"), Out: &out}
+			if err := sim.Run(); err != nil {
+				t.Fatalf("simulation failed: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("expected output to contain %q, got:\n%s", want, out.String())
+				}
+			}
+			for _, skip := range tt.skip {
+				if strings.Contains(out.String(), skip) {
+					t.Errorf("expected output without %q, got:\n%s", skip, out.String())
+				}
+			}
+		})
+	}
+}
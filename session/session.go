@@ -0,0 +1,315 @@
+package session
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"go-tg-support-ticket/form"
+	"time"
+)
+
+// Session holds the in-progress state of a single chat filling a form.
+// It is created from the form template on /start, so answers never leak
+// between users and the template itself is never mutated.
+type Session struct {
+	ID        string            `json:"id"` // Submission ID, a UUID; the ticket is stored with it
+	ChatID    int64             `json:"chat_id"`
+	User      *User             `json:"user,omitempty"`      // Telegram user who started the form
+	FormName  string            `json:"form_name"`           // Name of the form template
+	FormSlug  string            `json:"form_slug"`           // Slug of the form template, used to rebind after a restart
+	Step      int               `json:"step"`                // Index of the current field
+	History   []int             `json:"history,omitempty"`   // Steps visited before the current one, for /back
+	Modifying string            `json:"modifying,omitempty"` // Name of the field being modified from review
+	Answers   map[string]string `json:"answers"`             // Field name -> validated user value
+	Skipped   map[string]bool   `json:"skipped,omitempty"`   // Fields the user skipped; their answer is empty
+	Files     []form.File       `json:"files,omitempty"`     // Files received for the current file field
+	Selected  []string          `json:"selected,omitempty"`  // Options toggled on for the current multiselect field
+	Deadline  time.Time         `json:"deadline"`            // Session expires if there is no activity until then
+
+	// Repeatable group in progress
+	InItem  bool                `json:"in_item,omitempty"`  // User is filling an item of the current group
+	SubStep int                 `json:"sub_step,omitempty"` // Index of the current sub-field within the item
+	Item    map[string]string   `json:"item,omitempty"`     // Answers of the item being filled
+	Items   []map[string]string `json:"items,omitempty"`    // Completed items of the current group
+
+	Form *form.Form `json:"-"` // Template the session was started from
+}
+
+// User is the Telegram user filling in a form
+type User struct {
+	ID           int64  `json:"id"`
+	Username     string `json:"username,omitempty"`
+	FirstName    string `json:"first_name"`
+	LastName     string `json:"last_name,omitempty"`
+	LanguageCode string `json:"language_code,omitempty"`
+}
+
+// NewID returns a new submission ID. UUIDv7 keeps stored tickets in the
+// order they were started.
+func NewID() string {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return uuid.NewString()
+	}
+	return id.String()
+}
+
+// New starts a fresh session for chatID on the given form template.
+func New(chatID int64, f *form.Form) *Session {
+	s := &Session{
+		ID:       NewID(),
+		ChatID:   chatID,
+		FormName: f.FormName,
+		FormSlug: f.Slug,
+		Answers:  make(map[string]string),
+		Form:     f,
+	}
+	s.Step = f.FirstStep(s.Answers)
+	return s
+}
+
+// Advance moves the session to the next field according to the form flow.
+func (s *Session) Advance() {
+	s.History = append(s.History, s.Step)
+	s.Step = s.Form.NextStep(s.Step, s.Answers)
+}
+
+// CanGoBack reports whether Back has anywhere to go.
+func (s *Session) CanGoBack() bool {
+	return s.InItem || len(s.Items) > 0 || len(s.History) > 0
+}
+
+// Back returns to the previous input the user visited, so fields skipped
+// by the flow are never revisited. Answers are kept, so they can be offered
+// as the default. Within a group it steps back through the sub-fields of
+// the items; going back past the first sub-field of an item removes the
+// item, and returning to a finished group reopens its items. It returns
+// false if there is nothing to go back to.
+func (s *Session) Back() bool {
+	s.Files = nil
+	s.Selected = nil
+	if s.InItem {
+		if s.SubStep > 0 {
+			s.SubStep--
+		} else {
+			s.InItem = false
+			s.Item = nil
+		}
+		return true
+	}
+	if len(s.Items) > 0 {
+		// Reopen the last item at its last sub-field
+		field, _ := s.CurrentField()
+		s.Item = s.Items[len(s.Items)-1]
+		s.Items = s.Items[:len(s.Items)-1]
+		s.InItem = true
+		s.SubStep = len(field.Fields) - 1
+		return true
+	}
+	if len(s.History) == 0 {
+		return false
+	}
+
+	s.Step = s.History[len(s.History)-1]
+	s.History = s.History[:len(s.History)-1]
+	s.SubStep = 0
+	s.Item = nil
+	s.Items = nil
+
+	if field, ok := s.CurrentField(); ok && field.Type == form.GroupType {
+		field.UserValue = s.Answers[field.Name]
+		if items, err := field.GroupItems(); err == nil {
+			s.Items = items
+		}
+	}
+	return true
+}
+
+// Previous returns the earlier answer to the current input, which exists
+// when the user came back to it and did not skip it.
+func (s *Session) Previous() (string, bool) {
+	field, ok := s.CurrentField()
+	if !ok {
+		return "", false
+	}
+	if field.Type != form.GroupType {
+		value, ok := s.Answers[field.Name]
+		return value, ok && !s.Skipped[field.Name]
+	}
+	if !s.InItem || s.SubStep >= len(field.Fields) {
+		return "", false
+	}
+	value, ok := s.Item[field.Fields[s.SubStep].Name]
+	return value, ok && value != "" // Skipped sub-fields are empty
+}
+
+// Resume moves the session to the first unanswered field on the current
+// path. It returns false if every field on the path has been answered,
+// e.g. a modification from the review screen did not change the flow.
+func (s *Session) Resume() bool {
+	for _, step := range s.Form.Path(s.Answers) {
+		if _, ok := s.Answers[s.Form.Fields[step].Name]; !ok {
+			s.Step = step
+			return true
+		}
+	}
+	s.Step = len(s.Form.Fields)
+	return false
+}
+
+// CurrentField returns the field for the current step, or false if the
+// user has gone past the last field.
+func (s *Session) CurrentField() (form.Field, bool) {
+	if s.Step < 0 || s.Step >= len(s.Form.Fields) {
+		return form.Field{}, false
+	}
+	return s.Form.Fields[s.Step], true
+}
+
+// CurrentInput returns the field the user is expected to answer right now.
+// Inside a group this is the current sub-field; between group items there
+// is no input and false is returned.
+func (s *Session) CurrentInput() (form.Field, bool) {
+	field, ok := s.CurrentField()
+	if !ok || field.Type != form.GroupType {
+		return field, ok
+	}
+	if !s.InItem || s.SubStep >= len(field.Fields) {
+		return form.Field{}, false
+	}
+	return field.Fields[s.SubStep], true
+}
+
+// Record stores value as the answer to the current input and moves on.
+// Inside a group the value goes into the current item; the item is
+// completed after its last sub-field.
+func (s *Session) Record(value string) {
+	field, ok := s.CurrentField()
+	if !ok {
+		return
+	}
+	if field.Type != form.GroupType {
+		s.SetAnswer(field.Name, value)
+		s.Advance()
+		return
+	}
+	if !s.InItem || s.SubStep >= len(field.Fields) {
+		return
+	}
+
+	if s.Item == nil {
+		s.Item = make(map[string]string)
+	}
+	s.Item[field.Fields[s.SubStep].Name] = value
+	s.SubStep++
+	if s.SubStep >= len(field.Fields) {
+		s.Items = append(s.Items, s.Item)
+		s.Item = nil
+		s.SubStep = 0
+		s.InItem = false
+	}
+}
+
+// Skip records that the user skipped the current input and moves on. The
+// answer stays empty, so no typed value is ever taken for a skip.
+func (s *Session) Skip() {
+	field, ok := s.CurrentField()
+	s.Record("")
+	if !ok || field.Type == form.GroupType {
+		return
+	}
+	if s.Skipped == nil {
+		s.Skipped = make(map[string]bool)
+	}
+	s.Skipped[field.Name] = true
+}
+
+// StartItem begins a new item of the current group.
+func (s *Session) StartItem() {
+	s.InItem = true
+	s.SubStep = 0
+	s.Item = nil
+}
+
+// FinishGroup stores the completed items as the JSON answer of the
+// current group and moves on to the next field.
+func (s *Session) FinishGroup() error {
+	field, ok := s.CurrentField()
+	if !ok || field.Type != form.GroupType {
+		return nil
+	}
+
+	items := s.Items
+	if items == nil {
+		items = []map[string]string{}
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+
+	s.SetAnswer(field.Name, string(data))
+	s.InItem = false
+	s.SubStep = 0
+	s.Item = nil
+	s.Items = nil
+	s.Advance()
+	return nil
+}
+
+// Reset forgets the answer of the named field so it is asked again.
+func (s *Session) Reset(name string) {
+	delete(s.Answers, name)
+	delete(s.Skipped, name)
+	s.InItem = false
+	s.SubStep = 0
+	s.Item = nil
+	s.Items = nil
+}
+
+// Answer returns the stored value for the named field.
+func (s *Session) Answer(name string) string {
+	return s.Answers[name]
+}
+
+// SetAnswer stores the value for the named field.
+func (s *Session) SetAnswer(name, value string) {
+	s.Answers[name] = value
+	delete(s.Skipped, name)
+}
+
+// Fields returns a copy of the template fields with the session answers
+// filled in as UserValue. This is what gets persisted and sent out.
+// Fields that are not on the path of the current answers stay empty.
+func (s *Session) Fields() []form.Field {
+	fields := make([]form.Field, len(s.Form.Fields))
+	copy(fields, s.Form.Fields)
+	for _, step := range s.Form.Path(s.Answers) {
+		fields[step].UserValue = s.Answers[fields[step].Name]
+		fields[step].Skipped = s.Skipped[fields[step].Name]
+	}
+	return fields
+}
+
+// VisibleFields returns only the fields on the path of the current answers,
+// with their values filled in. This is what the user reviews.
+func (s *Session) VisibleFields() []form.Field {
+	path := s.Form.Path(s.Answers)
+	fields := make([]form.Field, 0, len(path))
+	for _, step := range path {
+		field := s.Form.Fields[step]
+		field.UserValue = s.Answers[field.Name]
+		field.Skipped = s.Skipped[field.Name]
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// FieldByName returns the template field with the given name.
+func (s *Session) FieldByName(name string) (form.Field, bool) {
+	for _, field := range s.Form.Fields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	return form.Field{}, false
+}
@@ -0,0 +1,275 @@
+package session
+
+import (
+	"github.com/google/uuid"
+	"go-tg-support-ticket/form"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSessionsDoNotShareAnswers(t *testing.T) {
+	tmpl := &form.Form{
+		FormName: "support",
+		Fields: []form.Field{
+			{Name: "name", Type: "text"},
+			{Name: "email", Type: "email"},
+		},
+	}
+
+	a := New(1, tmpl)
+	b := New(2, tmpl)
+
+	a.SetAnswer("name", "Alice")
+	a.Step++
+	b.SetAnswer("name", "Bob")
+
+	if got := a.Fields()[0].UserValue; got != "Alice" {
+		t.Errorf("expected session a to keep its answer, got %q", got)
+	}
+	if got := b.Fields()[0].UserValue; got != "Bob" {
+		t.Errorf("expected session b to keep its answer, got %q", got)
+	}
+	if b.Step != 0 {
+		t.Errorf("expected session b to stay on step 0, got %d", b.Step)
+	}
+	for _, field := range tmpl.Fields {
+		if field.UserValue != "" {
+			t.Errorf("template field %s was mutated: %q", field.Name, field.UserValue)
+		}
+	}
+
+	c := New(1, tmpl)
+	if _, ok := c.Answers["name"]; ok {
+		t.Errorf("expected a fresh session to start without answers")
+	}
+}
+
+func TestSkipIsNotAnAnswer(t *testing.T) {
+	s := New(1, &form.Form{Fields: []form.Field{
+		{Name: "phone", Type: "text", Skippable: true},
+		{Name: "note", Type: "text"},
+	}})
+	s.Skip()
+	s.Record("skipped")
+
+	fields := s.Fields()
+	if !fields[0].Skipped || !fields[0].IsNull() {
+		t.Errorf("expected phone to be skipped, got %+v", fields[0])
+	}
+	if fields[1].Skipped || fields[1].IsNull() {
+		t.Errorf("expected the typed text to be an answer, got %+v", fields[1])
+	}
+	if _, ok := s.Answers["phone"]; !ok {
+		t.Error("expected the skipped field to count as visited")
+	}
+
+	s.SetAnswer("phone", "+14155550123")
+	if s.Fields()[0].Skipped {
+		t.Error("expected a new answer to clear the skip")
+	}
+}
+
+func TestFileStoreSurvivesReopen(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sessions")
+	tmpl := &form.Form{FormName: "support", Fields: []form.Field{{Name: "name", Type: "text"}}}
+
+	st, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("failed to open file store: %v", err)
+	}
+
+	s := New(42, tmpl)
+	s.User = &User{ID: 42, Username: "alice", FirstName: "Alice"}
+	s.Step = 1
+	s.SetAnswer("name", "Alice")
+	s.Deadline = time.Now().Add(time.Hour).Truncate(time.Second)
+	if err := st.Save(s); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	if err := st.Save(New(7, tmpl)); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	if err := st.Delete(7); err != nil {
+		t.Fatalf("failed to delete session: %v", err)
+	}
+
+	reopened, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("failed to reopen file store: %v", err)
+	}
+	sessions, err := reopened.LoadAll()
+	if err != nil {
+		t.Fatalf("failed to load sessions: %v", err)
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("expected 1 session, got %d", len(sessions))
+	}
+
+	got := sessions[0]
+	if got.ChatID != 42 || got.Step != 1 || got.FormName != "support" || got.Answer("name") != "Alice" {
+		t.Errorf("unexpected restored session: %+v", got)
+	}
+	if !got.Deadline.Equal(s.Deadline) {
+		t.Errorf("expected deadline %v, got %v", s.Deadline, got.Deadline)
+	}
+	if got.ID == "" || got.ID != s.ID {
+		t.Errorf("expected submission ID %q, got %q", s.ID, got.ID)
+	}
+	if got.User == nil || *got.User != *s.User {
+		t.Errorf("expected user %+v, got %+v", s.User, got.User)
+	}
+}
+
+func TestUnmarshalAssignsMissingID(t *testing.T) {
+	s, err := Unmarshal([]byte(`{"chat_id":1,"form_name":"support"}`))
+	if err != nil {
+		t.Fatalf("failed to unmarshal session: %v", err)
+	}
+	if _, err := uuid.Parse(s.ID); err != nil {
+		t.Errorf("expected a UUID for a session stored without an ID, got %q", s.ID)
+	}
+}
+
+func TestGroupItems(t *testing.T) {
+	tmpl := &form.Form{
+		FormName: "order",
+		Fields: []form.Field{
+			{Name: "lines", Type: form.GroupType, Fields: []form.Field{
+				{Name: "product", Type: "text"},
+				{Name: "quantity", Type: "number"},
+			}},
+			{Name: "email", Type: "email"},
+		},
+	}
+
+	s := New(1, tmpl)
+	if _, ok := s.CurrentInput(); ok {
+		t.Fatalf("expected no input before an item is started")
+	}
+
+	for _, line := range [][2]string{{"Keyboard", "1"}, {"Mouse", "2"}} {
+		s.StartItem()
+		s.Record(line[0])
+		if field, _ := s.CurrentInput(); field.Name != "quantity" {
+			t.Fatalf("expected quantity to be asked next, got %q", field.Name)
+		}
+		s.Record(line[1])
+	}
+	if len(s.Items) != 2 || s.InItem {
+		t.Fatalf("expected 2 completed items, got %+v", s.Items)
+	}
+
+	if err := s.FinishGroup(); err != nil {
+		t.Fatalf("failed to finish group: %v", err)
+	}
+	if field, _ := s.CurrentInput(); field.Name != "email" {
+		t.Fatalf("expected email to be asked after the group, got %q", field.Name)
+	}
+
+	items, err := s.Fields()[0].GroupItems()
+	if err != nil {
+		t.Fatalf("failed to decode group items: %v", err)
+	}
+	if len(items) != 2 || items[1]["product"] != "Mouse" || items[1]["quantity"] != "2" {
+		t.Errorf("unexpected group items: %+v", items)
+	}
+}
+
+func TestBackFollowsVisitedSteps(t *testing.T) {
+	billing := "billing"
+	tmpl := &form.Form{
+		FormName: "ticket",
+		Fields: []form.Field{
+			{Name: "category", Type: "select"},
+			{Name: "invoice", Type: "text", ShowIf: &form.Condition{Field: "category", Equals: &billing}},
+			{Name: "details", Type: "text"},
+			{Name: "lines", Type: form.GroupType, Fields: []form.Field{
+				{Name: "product", Type: "text"},
+				{Name: "quantity", Type: "number"},
+			}},
+		},
+	}
+
+	s := New(1, tmpl)
+	if s.CanGoBack() || s.Back() {
+		t.Fatal("expected nothing to go back to on the first step")
+	}
+
+	s.Record("technical") // invoice is hidden
+	s.Record("skipped")
+	if field, _ := s.CurrentField(); field.Name != "lines" {
+		t.Fatalf("expected lines, got %q", field.Name)
+	}
+
+	s.StartItem()
+	s.Record("Keyboard")
+	s.Record("1")
+
+	// Back into the completed item, then out of the group
+	steps := []struct {
+		input    string
+		previous string
+	}{
+		{"quantity", "1"},
+		{"product", "Keyboard"},
+		{"", ""}, // Group prompt, the item is removed
+		{"details", "skipped"},
+		{"category", "technical"},
+	}
+	for _, step := range steps {
+		if !s.Back() {
+			t.Fatalf("expected to go back to %q", step.input)
+		}
+		field, ok := s.CurrentInput()
+		if step.input == "" {
+			if ok || len(s.Items) != 0 {
+				t.Fatalf("expected the group prompt without items, got %q and %d items", field.Name, len(s.Items))
+			}
+			continue
+		}
+		if field.Name != step.input {
+			t.Fatalf("expected %q, got %q", step.input, field.Name)
+		}
+		if previous, _ := s.Previous(); previous != step.previous {
+			t.Errorf("%s: expected previous answer %q, got %q", step.input, step.previous, previous)
+		}
+	}
+	if s.Back() {
+		t.Fatal("expected nothing to go back to on the first step")
+	}
+
+	// Changing the category shows the invoice field now
+	s.Record("billing")
+	if field, _ := s.CurrentField(); field.Name != "invoice" {
+		t.Fatalf("expected invoice, got %q", field.Name)
+	}
+	s.Record("INV-1")
+	if previous, ok := s.Previous(); !ok || previous != "skipped" {
+		t.Errorf("expected details to keep its answer, got %q", previous)
+	}
+}
+
+func TestBackReopensFinishedGroup(t *testing.T) {
+	tmpl := &form.Form{
+		FormName: "order",
+		Fields: []form.Field{
+			{Name: "lines", Type: form.GroupType, Fields: []form.Field{{Name: "product", Type: "text"}}},
+			{Name: "email", Type: "email"},
+		},
+	}
+
+	s := New(1, tmpl)
+	s.StartItem()
+	s.Record("Keyboard")
+	if err := s.FinishGroup(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !s.Back() {
+		t.Fatal("expected to go back to the group")
+	}
+	if len(s.Items) != 1 || s.Items[0]["product"] != "Keyboard" {
+		t.Fatalf("expected the finished items to be reopened, got %v", s.Items)
+	}
+}
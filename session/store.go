@@ -0,0 +1,142 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-tg-support-ticket/internal/fileutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// Store persists sessions so in-progress forms survive restarts.
+// Loaded sessions are not bound to a form; the caller sets Form again.
+type Store interface {
+	Save(s *Session) error
+	Delete(chatID int64) error
+	LoadAll() ([]*Session, error)
+}
+
+// memoryStore keeps sessions in process memory only
+type memoryStore struct {
+	mu       sync.Mutex
+	sessions map[int64][]byte
+}
+
+// NewMemoryStore returns a Store that forgets everything on restart.
+func NewMemoryStore() Store {
+	return &memoryStore{sessions: make(map[int64][]byte)}
+}
+
+func (m *memoryStore) Save(s *Session) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[s.ChatID] = data
+	return nil
+}
+
+func (m *memoryStore) Delete(chatID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, chatID)
+	return nil
+}
+
+func (m *memoryStore) LoadAll() ([]*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sessions := make([]*Session, 0, len(m.sessions))
+	for _, data := range m.sessions {
+		s, err := Unmarshal(data)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, nil
+}
+
+// fileStore keeps every session in its own JSON file named by the chat ID,
+// so saving a session only rewrites the file of its chat.
+type fileStore struct {
+	dir string
+}
+
+// NewFileStore returns a Store backed by JSON files in dir.
+// The directory is created if it does not exist.
+func NewFileStore(dir string) (Store, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("session dir is empty")
+	}
+	f := &fileStore{dir: filepath.Clean(dir)}
+	if err := os.MkdirAll(f.dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create session dir: %w", err)
+	}
+	return f, nil
+}
+
+func (f *fileStore) path(chatID int64) string {
+	return filepath.Join(f.dir, strconv.FormatInt(chatID, 10)+".json")
+}
+
+func (f *fileStore) Save(s *Session) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+	if err := fileutil.WriteFile(f.path(s.ChatID), data, 0o600); err != nil {
+		return fmt.Errorf("failed to write session file: %w", err)
+	}
+	return nil
+}
+
+func (f *fileStore) Delete(chatID int64) error {
+	if err := os.Remove(f.path(chatID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove session file: %w", err)
+	}
+	return nil
+}
+
+func (f *fileStore) LoadAll() ([]*Session, error) {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session dir: %w", err)
+	}
+	sessions := make([]*Session, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(f.dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read session file: %w", err)
+		}
+		s, err := Unmarshal(data)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, nil
+}
+
+// Unmarshal decodes a session previously encoded with json.Marshal.
+func Unmarshal(data []byte) (*Session, error) {
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal session: %w", err)
+	}
+	if s.Answers == nil {
+		s.Answers = make(map[string]string)
+	}
+	if s.ID == "" { // Stored before sessions had an ID
+		s.ID = NewID()
+	}
+	return &s, nil
+}
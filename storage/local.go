@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"go-tg-support-ticket/internal/fileutil"
+	"os"
+	"path/filepath"
+)
+
+// LocalConfig holds the settings of the local filesystem backend
+type LocalConfig struct {
+	Dir string `mapstructure:"dir"` // Directory the files are written to
+}
+
+type local struct {
+	dir string
+}
+
+// NewLocal returns a backend that writes files below cfg.Dir
+func NewLocal(cfg LocalConfig) (Backend, error) {
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("local storage requires a dir")
+	}
+	dir, err := filepath.Abs(cfg.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve storage dir: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create storage dir: %w", err)
+	}
+	return &local{dir: dir}, nil
+}
+
+func (l *local) Name() string {
+	return "local"
+}
+
+// Put writes the file through a synced temporary file, so a crash never
+// leaves a partial file under its final name. The reference is a file:// URL.
+func (l *local) Put(_ context.Context, key string, data []byte, _ string) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	name := filepath.Join(l.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(name), 0o750); err != nil {
+		return "", fmt.Errorf("failed to create directory for %s: %w", key, err)
+	}
+	if err := fileutil.WriteFile(name, data, 0o600); err != nil {
+		return "", fmt.Errorf("failed to store %s: %w", key, err)
+	}
+
+	return "file://" + filepath.ToSlash(name), nil
+}
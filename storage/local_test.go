@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalPut(t *testing.T) {
+	dir := t.TempDir()
+	b, err := NewLocal(LocalConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		key       string
+		expectErr bool
+	}{
+		{"Nested key", "help-desk/42/report.pdf", false},
+		{"Parent directory", "../outside.pdf", true},
+		{"Empty key", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ref, err := b.Put(context.Background(), test.key, []byte("content"), "application/pdf")
+			if test.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error, got ref %s", ref)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			name := filepath.Join(dir, filepath.FromSlash(test.key))
+			if want := "file://" + filepath.ToSlash(name); ref != want {
+				t.Errorf("expected ref %s, got %s", want, ref)
+			}
+			data, err := os.ReadFile(name)
+			if err != nil || string(data) != "content" {
+				t.Errorf("expected the file content, got %q (%v)", data, err)
+			}
+		})
+	}
+}
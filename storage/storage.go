@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Config holds the file storage settings
+type Config struct {
+	Type  string      `mapstructure:"type"` // "none", "local" or "s3"
+	Local LocalConfig `mapstructure:"local"`
+	S3    S3Config    `mapstructure:"s3"`
+}
+
+// Backend keeps copies of uploaded files
+type Backend interface {
+	// Put stores data under key and returns a stable reference to it
+	Put(ctx context.Context, key string, data []byte, contentType string) (string, error)
+	Name() string
+}
+
+// Files is the configured backend. It is nil when uploads are not copied,
+// in which case only the Telegram file_id is kept.
+var Files Backend
+
+// NewStorage initializes Files from the configuration
+func NewStorage(cfg *Config) error {
+	Files = nil
+	if cfg == nil {
+		return nil
+	}
+
+	switch cfg.Type {
+	case "", "none":
+		return nil
+	case "local":
+		b, err := NewLocal(cfg.Local)
+		if err != nil {
+			return err
+		}
+		Files = b
+	case "s3":
+		b, err := NewS3(cfg.S3)
+		if err != nil {
+			return err
+		}
+		Files = b
+	default:
+		return fmt.Errorf("unknown storage type %q, expected none, local or s3", cfg.Type)
+	}
+	return nil
+}
+
+// cleanKey rejects keys that are empty or would escape the storage root
+func cleanKey(key string) (string, error) {
+	cleaned := path.Clean("/" + key)[1:]
+	if key == "" || cleaned == "" || cleaned != strings.TrimPrefix(key, "/") {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return cleaned, nil
+}
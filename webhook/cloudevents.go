@@ -0,0 +1,69 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Payload formats of an endpoint
+const (
+	JSONFormat        = "json"        // The event as documented, the default
+	CloudEventsFormat = "cloudevents" // The event as data of a CloudEvents 1.0 structured mode message
+)
+
+// Content types of the payload formats
+const (
+	jsonContentType        = "application/json"
+	cloudEventsContentType = "application/cloudevents+json"
+)
+
+// cloudEventsSpecVersion is the CloudEvents version of the messages
+const cloudEventsSpecVersion = "1.0"
+
+// CloudEvent is an event in the JSON format of CloudEvents 1.0
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`      // Delivery ID
+	Source          string          `json:"source"`  // "/forms/<slug>"
+	Type            string          `json:"type"`    // Event type, e.g. form.submitted
+	Subject         string          `json:"subject"` // Submission ID
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data"` // The event as sent in the json format
+}
+
+// validateFormat checks the payload format of an endpoint
+func validateFormat(format string) error {
+	switch strings.ToLower(format) {
+	case "", JSONFormat, CloudEventsFormat:
+		return nil
+	}
+	return fmt.Errorf("unknown format %q, must be %q or %q", format, JSONFormat, CloudEventsFormat)
+}
+
+// newCloudEvent wraps the event, whose JSON is data, for the CloudEvents format
+func newCloudEvent(e Event, data json.RawMessage) CloudEvent {
+	return CloudEvent{
+		SpecVersion:     cloudEventsSpecVersion,
+		ID:              e.DeliveryID,
+		Source:          "/forms/" + e.Form,
+		Type:            e.Type,
+		Subject:         e.SubmissionID,
+		Time:            e.Timestamp,
+		DataContentType: jsonContentType,
+		Data:            data,
+	}
+}
+
+// encode returns the payload of the event in the format of the endpoint and
+// its content type. data is the JSON of the event as stored in the outbox; it
+// is sent unchanged, so fields unknown to this version survive a replay.
+func (ep *endpoint) encode(e Event, data json.RawMessage) ([]byte, string, error) {
+	if strings.ToLower(ep.Format) == CloudEventsFormat {
+		payload, err := json.Marshal(newCloudEvent(e, data))
+		return payload, cloudEventsContentType, err
+	}
+	return data, jsonContentType, nil
+}
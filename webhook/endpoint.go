@@ -0,0 +1,129 @@
+package webhook
+
+import (
+	"fmt"
+	"go-tg-support-ticket/form"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultEndpoint is the name of the endpoint given by the url, auth and
+// retry settings at the top of the webhook config
+const DefaultEndpoint = "default"
+
+// defaultTimeout is the timeout of an attempt if the endpoint has none
+const defaultTimeout = 10 * time.Second
+
+// Endpoint is a receiver of webhook events. Every event goes to all endpoints
+// whose filters match it, each with its own delivery ID and retries.
+type Endpoint struct {
+	Name    string            `mapstructure:"name"` // Identifies the endpoint in logs and the dead-letter store
+	URL     string            `mapstructure:"url"`
+	Auth    Auth              `mapstructure:"auth"`
+	Headers map[string]string `mapstructure:"headers"` // Extra request headers
+	Format  string            `mapstructure:"format"`  // "json" or "cloudevents"; json if not set
+	Timeout time.Duration     `mapstructure:"timeout"` // Timeout of an attempt; 10s if not set
+	Retry   *RetryConfig      `mapstructure:"retry"`   // Retry policy; the defaults apply if not set
+
+	// Filters; an endpoint without filters gets the submissions of every form
+	Forms  []string        `mapstructure:"forms"`  // Slugs or names of the forms to send
+	Events []string        `mapstructure:"events"` // Event types to send, e.g. "session.*"; only form.submitted if not set
+	When   *form.Condition `mapstructure:"when"`   // Condition on the answers, as in show_if
+}
+
+// endpoint is a configured endpoint ready to send
+type endpoint struct {
+	Endpoint
+	retry  RetryConfig
+	client *http.Client
+}
+
+// newEndpoints checks the endpoints of the config. The top level URL becomes
+// the default endpoint; it is kept without a URL if there are no other
+// endpoints, so forms with their own webhook_url still get their events.
+func newEndpoints(cfg *Config) ([]*endpoint, error) {
+	configs := make([]Endpoint, 0, len(cfg.Endpoints)+1)
+	if cfg.URL != "" || len(cfg.Endpoints) == 0 {
+		configs = append(configs, Endpoint{Name: DefaultEndpoint, URL: cfg.URL, Auth: cfg.Auth, Retry: cfg.Retry, Format: cfg.Format, Events: cfg.Events})
+	}
+	configs = append(configs, cfg.Endpoints...)
+
+	endpoints := make([]*endpoint, 0, len(configs))
+	seen := make(map[string]bool, len(configs))
+	for i, c := range configs {
+		if c.Name == "" {
+			return nil, fmt.Errorf("webhook endpoint %d has no name", i+1)
+		}
+		if seen[c.Name] {
+			return nil, fmt.Errorf("webhook endpoint name %q is used twice", c.Name)
+		}
+		seen[c.Name] = true
+		if c.URL == "" && c.Name != DefaultEndpoint {
+			return nil, fmt.Errorf("webhook endpoint %q has no url", c.Name)
+		}
+		if strings.ToLower(c.Auth.Type) == "hmac" && len(c.Auth.Secrets) == 0 {
+			return nil, fmt.Errorf("auth.secrets of webhook endpoint %q must not be empty for the hmac auth type", c.Name)
+		}
+		if err := validateFormat(c.Format); err != nil {
+			return nil, fmt.Errorf("invalid format of webhook endpoint %q: %w", c.Name, err)
+		}
+		if c.When != nil {
+			if err := validateCondition(c.When); err != nil {
+				return nil, fmt.Errorf("invalid condition of webhook endpoint %q: %w", c.Name, err)
+			}
+		}
+
+		timeout := c.Timeout
+		if timeout <= 0 {
+			timeout = defaultTimeout
+		}
+		endpoints = append(endpoints, &endpoint{
+			Endpoint: c,
+			retry:    newRetryPolicy(c.Retry),
+			client:   &http.Client{Timeout: timeout},
+		})
+	}
+	return endpoints, nil
+}
+
+// validateCondition checks the regexes of the condition, which only fail
+// silently when it is evaluated
+func validateCondition(c *form.Condition) error {
+	if c.Regex != "" {
+		if _, err := regexp.Compile(c.Regex); err != nil {
+			return err
+		}
+	}
+	for _, nested := range append(slices.Clone(c.And), c.Or...) {
+		if err := validateCondition(&nested); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// url returns where the event is sent, "" if nowhere. A form specific URL
+// replaces the URL of the default endpoint.
+func (ep *endpoint) url(e Event) string {
+	if ep.Name == DefaultEndpoint && e.URL != "" {
+		return e.URL
+	}
+	return ep.URL
+}
+
+// matches reports whether the event passes the filters of the endpoint
+func (ep *endpoint) matches(e Event) bool {
+	if ep.url(e) == "" {
+		return false
+	}
+	if len(ep.Forms) > 0 && !slices.Contains(ep.Forms, e.Form) && !slices.Contains(ep.Forms, e.Event) {
+		return false
+	}
+	if !matchEventType(ep.Events, e.Type) {
+		return false
+	}
+	return ep.When == nil || ep.When.Evaluate(e.Answers)
+}
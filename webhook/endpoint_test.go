@@ -0,0 +1,159 @@
+package webhook
+
+import (
+	"context"
+	"go-tg-support-ticket/form"
+	"go-tg-support-ticket/session"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestEndpointMatches(t *testing.T) {
+	high := "high"
+	e := Event{Event: "Support Ticket", Form: "support", Type: FormSubmitted, Answers: map[string]string{"priority": "high"}}
+	tests := []struct {
+		name     string
+		endpoint Endpoint
+		event    Event
+		want     bool
+	}{
+		{name: "No filters", endpoint: Endpoint{URL: "http://a"}, event: e, want: true},
+		{name: "No URL", endpoint: Endpoint{}, event: e, want: false},
+		{name: "Form slug", endpoint: Endpoint{URL: "http://a", Forms: []string{"billing", "support"}}, event: e, want: true},
+		{name: "Form name", endpoint: Endpoint{URL: "http://a", Forms: []string{"Support Ticket"}}, event: e, want: true},
+		{name: "Other form", endpoint: Endpoint{URL: "http://a", Forms: []string{"billing"}}, event: e, want: false},
+		{name: "Event type", endpoint: Endpoint{URL: "http://a", Events: []string{FormSubmitted}}, event: e, want: true},
+		{name: "Other event type", endpoint: Endpoint{URL: "http://a", Events: []string{SessionStarted}}, event: e, want: false},
+		{name: "Only submissions by default", endpoint: Endpoint{URL: "http://a"}, event: Event{Type: FieldAnswered}, want: false},
+		{name: "All event types", endpoint: Endpoint{URL: "http://a", Events: []string{"*"}}, event: Event{Type: FieldAnswered}, want: true},
+		{name: "Event type prefix", endpoint: Endpoint{URL: "http://a", Events: []string{"session.*"}}, event: Event{Type: SessionTimedOut}, want: true},
+		{name: "Other event type prefix", endpoint: Endpoint{URL: "http://a", Events: []string{"session.*"}}, event: Event{Type: FieldSkipped}, want: false},
+		{name: "Condition holds", endpoint: Endpoint{URL: "http://a", When: &form.Condition{Field: "priority", Equals: &high}}, event: e, want: true},
+		{name: "Condition fails", endpoint: Endpoint{URL: "http://a", When: &form.Condition{Field: "priority", In: []string{"low", "medium"}}}, event: e, want: false},
+		{name: "Form URL of the default endpoint", endpoint: Endpoint{Name: DefaultEndpoint}, event: Event{Type: FormSubmitted, URL: "http://form"}, want: true},
+		{name: "Form URL of another endpoint", endpoint: Endpoint{Name: "crm"}, event: Event{Type: FormSubmitted, URL: "http://form"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ep := &endpoint{Endpoint: tt.endpoint}
+			if got := ep.matches(tt.event); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewEndpoints(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		want    []string
+		wantErr bool
+	}{
+		{name: "Only the default endpoint", cfg: Config{URL: "http://a"}, want: []string{DefaultEndpoint}},
+		{name: "Default endpoint without URL", cfg: Config{}, want: []string{DefaultEndpoint}},
+		{name: "Default and further endpoints", cfg: Config{URL: "http://a", Endpoints: []Endpoint{{Name: "crm", URL: "http://b"}}}, want: []string{DefaultEndpoint, "crm"}},
+		{name: "Only further endpoints", cfg: Config{Endpoints: []Endpoint{{Name: "crm", URL: "http://b"}}}, want: []string{"crm"}},
+		{name: "Missing name", cfg: Config{Endpoints: []Endpoint{{URL: "http://b"}}}, wantErr: true},
+		{name: "Duplicate name", cfg: Config{URL: "http://a", Endpoints: []Endpoint{{Name: DefaultEndpoint, URL: "http://b"}}}, wantErr: true},
+		{name: "Missing URL", cfg: Config{Endpoints: []Endpoint{{Name: "crm"}}}, wantErr: true},
+		{name: "HMAC without secrets", cfg: Config{Endpoints: []Endpoint{{Name: "crm", URL: "http://b", Auth: Auth{Type: "hmac"}}}}, wantErr: true},
+		{name: "CloudEvents format", cfg: Config{URL: "http://a", Format: CloudEventsFormat, Endpoints: []Endpoint{{Name: "crm", URL: "http://b", Format: JSONFormat}}}, want: []string{DefaultEndpoint, "crm"}},
+		{name: "Unknown format", cfg: Config{Endpoints: []Endpoint{{Name: "crm", URL: "http://b", Format: "xml"}}}, wantErr: true},
+		{name: "Invalid regex", cfg: Config{Endpoints: []Endpoint{{Name: "crm", URL: "http://b", When: &form.Condition{Or: []form.Condition{{Field: "title", Regex: "("}}}}}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoints, err := newEndpoints(&tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			var names []string
+			for _, ep := range endpoints {
+				names = append(names, ep.Name)
+			}
+			if len(names) != len(tt.want) {
+				t.Fatalf("got endpoints %v, want %v", names, tt.want)
+			}
+			for i := range names {
+				if names[i] != tt.want[i] {
+					t.Errorf("got endpoints %v, want %v", names, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestEndpointFanOut(t *testing.T) {
+	var mu sync.Mutex
+	received := make(map[string][]*http.Request)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		received[r.URL.Path] = append(received[r.URL.Path], r)
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	high := "high"
+	cfg := &Config{
+		Enabled:      true,
+		URL:          srv.URL + "/all",
+		WorkersCount: 2,
+		QueueSize:    10,
+		OutboxDir:    t.TempDir(),
+		Endpoints: []Endpoint{
+			{Name: "urgent", URL: srv.URL + "/urgent", Headers: map[string]string{"X-Team": "on-call"}, When: &form.Condition{Field: "priority", Equals: &high}},
+			{Name: "billing", URL: srv.URL + "/billing", Forms: []string{"billing"}},
+			{Name: "broken", URL: srv.URL + "/broken", Retry: &RetryConfig{MaxAttempts: 1}},
+		},
+	}
+	Workers = nil
+	if err := NewWebhookWorker(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	f := &form.Form{FormName: "Support Ticket", Slug: "support", Fields: []form.Field{{Name: "priority", Type: "text"}}}
+	for _, priority := range []string{"high", "low"} {
+		s := session.New(1, f)
+		s.Record(priority)
+		Workers.Enqueue(s)
+	}
+	if err := Workers.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]int{"/all": 2, "/urgent": 1, "/billing": 0, "/broken": 2}
+	ids := make(map[string]bool)
+	for path, n := range want {
+		if got := len(received[path]); got != n {
+			t.Errorf("%s: got %d requests, want %d", path, got, n)
+		}
+		for _, r := range received[path] {
+			ids[r.Header.Get(DeliveryIDHeader)] = true
+		}
+	}
+	if len(ids) != 5 {
+		t.Errorf("got %d delivery IDs, want one per endpoint and event", len(ids))
+	}
+	if r := received["/urgent"]; len(r) == 1 && r[0].Header.Get("X-Team") != "on-call" {
+		t.Errorf("got X-Team %q, want the endpoint header", r[0].Header.Get("X-Team"))
+	}
+
+	// Only the deliveries to the failing endpoint are dead-lettered
+	failed, err := Workers.(*worker).outbox.Failed()
+	if err != nil || len(failed) != 2 {
+		t.Fatalf("got failed %v, %v, want the two deliveries to broken", failed, err)
+	}
+	for _, r := range failed {
+		if r.Endpoint != "broken" {
+			t.Errorf("got failed delivery to %q, want broken", r.Endpoint)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package webhook
+
+import (
+	"go-tg-support-ticket/form"
+	"go-tg-support-ticket/logger"
+	"go-tg-support-ticket/session"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SchemaVersion is sent as schema_version in every payload. It is raised
+// when fields are removed or change their meaning, not when fields are added.
+const SchemaVersion = 1
+
+// Event types
+const (
+	SessionStarted  = "session.started"   // A form was started
+	FieldAnswered   = "field.answered"    // A question was answered
+	FieldSkipped    = "field.skipped"     // An optional question was skipped
+	ReviewModified  = "review.modified"   // An answer was changed from the review
+	SessionEnded    = "session.ended"     // The user ended the form with /end or started another one
+	SessionTimedOut = "session.timed_out" // The form was left inactive until the session timeout
+	FormSubmitted   = "form.submitted"    // The form was submitted
+)
+
+// defaultEvents are sent to endpoints without an events filter, so existing
+// receivers only get submissions
+var defaultEvents = []string{FormSubmitted}
+
+// Reasons of the session.ended event
+const (
+	EndedByCommand = "command"   // The user sent /end
+	EndedByRestart = "restarted" // The user started a form again
+)
+
+// NewSessionEvent builds an event of the given type about the session, e.g.
+// for the lifecycle of a form. data becomes the data object of the payload.
+func NewSessionEvent(s *session.Session, eventType string, data map[string]interface{}) Event {
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	answers := make(map[string]string)
+	for _, field := range s.Fields() {
+		if field.UserValue != "" {
+			answers[field.Name] = field.UserValue
+		}
+	}
+	return Event{
+		SchemaVersion: SchemaVersion,
+		Type:          eventType,
+		SubmissionID:  s.ID,
+		Event:         s.Form.FormName,
+		Form:          s.Form.Slug,
+		FormVersion:   s.Form.Version,
+		ChatID:        s.ChatID,
+		User:          s.User,
+		Timestamp:     time.Now().UTC(),
+		Data:          data,
+		URL:           s.Form.WebhookURL,
+		Answers:       answers,
+	}
+}
+
+// Field describes an answer of a submission
+type Field struct {
+	Name    string      `json:"name"`
+	Label   string      `json:"label"`
+	Type    string      `json:"type"`
+	Value   interface{} `json:"value"`   // As in data; nil if skipped or not asked
+	Skipped bool        `json:"skipped"` // The user skipped the field
+}
+
+// FieldValue returns the answer of the field as it is sent in events:
+// structured data for JSON fields, the text otherwise
+func FieldValue(s *session.Session, field form.Field, value string) interface{} {
+	field.UserValue = value
+	if !field.IsJSON() {
+		return value
+	}
+	v, err := field.JSONValue()
+	if err != nil {
+		logger.PrintLog(s.ChatID, "failed to decode field value for webhook", err)
+		return value
+	}
+	return v
+}
+
+// matchEventType reports whether one of the patterns matches the event
+// type. "*" matches every type and "session.*" every session event.
+func matchEventType(patterns []string, eventType string) bool {
+	if len(patterns) == 0 {
+		patterns = defaultEvents
+	}
+	return slices.ContainsFunc(patterns, func(pattern string) bool {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			return strings.HasPrefix(eventType, prefix)
+		}
+		return pattern == eventType
+	})
+}
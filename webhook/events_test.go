@@ -0,0 +1,170 @@
+package webhook
+
+import (
+	"encoding/json"
+	"go-tg-support-ticket/form"
+	"go-tg-support-ticket/session"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMatchEventType(t *testing.T) {
+	tests := []struct {
+		patterns  []string
+		eventType string
+		want      bool
+	}{
+		{nil, FormSubmitted, true},
+		{nil, SessionStarted, false},
+		{[]string{FieldAnswered, FieldSkipped}, FieldSkipped, true},
+		{[]string{FieldAnswered}, FormSubmitted, false},
+		{[]string{"*"}, SessionTimedOut, true},
+		{[]string{"session.*"}, SessionEnded, true},
+		{[]string{"session.*"}, ReviewModified, false},
+	}
+	for _, tt := range tests {
+		if got := matchEventType(tt.patterns, tt.eventType); got != tt.want {
+			t.Errorf("matchEventType(%v, %s) = %v, want %v", tt.patterns, tt.eventType, got, tt.want)
+		}
+	}
+}
+
+func TestPayloadSchema(t *testing.T) {
+	s := newTestSession()
+	s.Form.Slug = "ticket-form"
+	s.Form.Version = "2"
+	s.User = &session.User{ID: 7, Username: "ada", FirstName: "Ada"}
+	e := NewSessionEvent(s, FieldAnswered, map[string]interface{}{"field": "title", "value": "hello"})
+
+	// Events are kept as they are in the outbox for replays
+	r, err := newRecord(e, "delivery-1", DefaultEndpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored Record
+	data, _ := json.Marshal(r)
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := stored.Event()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := json.Marshal(replayed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(payload, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"schema_version": float64(SchemaVersion),
+		"type":           FieldAnswered,
+		"event":          "ticket",
+		"form":           "ticket-form",
+		"submission_id":  s.ID,
+		"form_version":   "2",
+		"chat_id":        float64(1),
+		"timestamp":      e.Timestamp.Format(time.RFC3339Nano),
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("%s: got %v, want %v", key, got[key], value)
+		}
+	}
+	if data, _ := got["data"].(map[string]interface{}); data["field"] != "title" || data["value"] != "hello" {
+		t.Errorf("got data %v", got["data"])
+	}
+	if user, _ := got["user"].(map[string]interface{}); user["id"] != float64(7) || user["username"] != "ada" || user["first_name"] != "Ada" {
+		t.Errorf("got user %v", got["user"])
+	}
+	if len(got) != len(want)+2 {
+		t.Errorf("got payload keys %v, want only the documented ones", got)
+	}
+
+	// Records keep the schema version they were stored with
+	old := &Record{ID: "old", Payload: json.RawMessage(`{"schema_version":0,"type":"form.submitted","event":"ticket"}`)}
+	if e, err := old.Event(); err != nil || e.SchemaVersion != 0 || e.Type != FormSubmitted || e.DeliveryID != "old" {
+		t.Errorf("got %+v, %v for an old record, want schema version 0", e, err)
+	}
+}
+
+func TestSubmissionFields(t *testing.T) {
+	f := &form.Form{FormName: "ticket", Fields: []form.Field{
+		{Name: "title", Label: "Title", Type: "text"},
+		{Name: "phone", Label: "Phone", Type: "text", Skippable: true},
+		{Name: "tags", Label: "Tags", Type: form.MultiselectType},
+	}}
+	s := session.New(1, f)
+	s.Record("hello")
+	s.Skip()
+	s.Record(`["a","b"]`)
+	e := NewEvent(s)
+
+	if e.SubmittedAt == nil || !e.SubmittedAt.Equal(e.Timestamp) {
+		t.Errorf("got submitted_at %v, want the event time %v", e.SubmittedAt, e.Timestamp)
+	}
+	if e.SubmissionID != s.ID {
+		t.Errorf("got submission ID %q, want %q", e.SubmissionID, s.ID)
+	}
+
+	payload, _ := json.Marshal(e.Fields)
+	want := `[{"name":"title","label":"Title","type":"text","value":"hello","skipped":false},` +
+		`{"name":"phone","label":"Phone","type":"text","value":null,"skipped":true},` +
+		`{"name":"tags","label":"Tags","type":"multiselect","value":["a","b"],"skipped":false}]`
+	if string(payload) != want {
+		t.Errorf("got fields %s, want %s", payload, want)
+	}
+}
+
+func TestCloudEventsFormat(t *testing.T) {
+	var header http.Header
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	w := newTestWorker(&Config{Enabled: true, URL: srv.URL, Format: CloudEventsFormat, Auth: Auth{Type: "hmac", Secrets: []string{"secret"}}})
+	s := newTestSession()
+	s.Form.Slug = "ticket-form"
+	e := NewEvent(s)
+	e.DeliveryID = "delivery-1"
+	if err := w.SendWebhook(e); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := header.Get("Content-Type"); got != "application/cloudevents+json" {
+		t.Errorf("got content type %q", got)
+	}
+	if err := Verify(header, body, []string{"secret"}, 0); err != nil {
+		t.Errorf("got error %v verifying the CloudEvents body", err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"specversion":     "1.0",
+		"id":              "delivery-1",
+		"source":          "/forms/ticket-form",
+		"type":            FormSubmitted,
+		"subject":         s.ID,
+		"time":            e.Timestamp.Format(time.RFC3339Nano),
+		"datacontenttype": "application/json",
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("%s: got %v, want %v", key, got[key], value)
+		}
+	}
+	if data, _ := got["data"].(map[string]interface{}); data["submission_id"] != s.ID || data["schema_version"] != float64(SchemaVersion) {
+		t.Errorf("got data %v, want the event", got["data"])
+	}
+}
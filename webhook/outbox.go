@@ -0,0 +1,290 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-tg-support-ticket/internal/fileutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Record is a delivery of a webhook event to an endpoint, kept in the outbox
+// until it is delivered, or in the dead-letter store once all attempts
+// failed. The event is kept as it was marshalled when it happened, so a
+// replay sends the payload of the schema version it was created with.
+type Record struct {
+	ID        string          `json:"id"`                   // Delivery ID
+	Endpoint  string          `json:"endpoint,omitempty"`   // Name of the endpoint; the default endpoint if empty
+	URL       string          `json:"url,omitempty"`        // Form specific URL
+	Payload   json.RawMessage `json:"payload"`              // The marshalled event
+	Attempts  int             `json:"attempts"`             // Delivery attempts so far
+	LastError string          `json:"last_error,omitempty"` // Why the last attempt failed
+	CreatedAt time.Time       `json:"created_at"`           // When the event happened
+	FailedAt  time.Time       `json:"failed_at,omitempty"`  // When it was moved to the dead-letter store
+}
+
+// newRecord wraps an event with a delivery ID to the endpoint for the outbox
+func newRecord(e Event, id, endpoint string) (*Record, error) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal webhook event: %w", err)
+	}
+	createdAt := e.Timestamp
+	if createdAt.IsZero() {
+		createdAt = time.Now().UTC()
+	}
+	return &Record{ID: id, Endpoint: endpoint, URL: e.URL, Payload: payload, CreatedAt: createdAt}, nil
+}
+
+// Event decodes the stored event for its routing and metadata. The payload
+// itself is delivered as stored.
+func (r *Record) Event() (Event, error) {
+	var e Event
+	if err := json.Unmarshal(r.Payload, &e); err != nil {
+		return e, fmt.Errorf("failed to unmarshal webhook event %s: %w", r.ID, err)
+	}
+	e.URL = r.URL
+	e.Endpoint = r.Endpoint
+	e.DeliveryID = r.ID
+	return e, nil
+}
+
+// Outbox keeps events from the submission until they are delivered. Events
+// whose delivery finally failed are moved to a dead-letter store.
+type Outbox interface {
+	Add(r *Record) error                // Adds a pending event
+	Delete(id string) error             // Removes a delivered pending event
+	Fail(r *Record) error               // Moves a pending event to the dead-letter store
+	Pending() ([]*Record, error)        // Pending events, oldest first
+	HasPending(id string) (bool, error) // Whether the event is still pending
+	Failed() ([]*Record, error)         // Dead-lettered events, oldest first
+	UpdateFailed(r *Record) error       // Stores a dead-lettered event again after a failed replay
+	DeleteFailed(id string) error       // Removes a dead-lettered event after it was replayed
+}
+
+// errNoDeadLetters is returned for failed events of an outbox in memory
+var errNoDeadLetters = errors.New("webhook.outbox_dir is not set, the failed event is dropped")
+
+// NewOutbox returns a file outbox in dir, or an outbox in process memory if
+// dir is empty
+func NewOutbox(dir string) (Outbox, error) {
+	if dir == "" {
+		return &memoryOutbox{pending: make(map[string]*Record)}, nil
+	}
+	o := &fileOutbox{dir: filepath.Clean(dir)}
+	for _, sub := range []string{pendingDir, failedDir} {
+		if err := os.MkdirAll(filepath.Join(o.dir, sub), 0o700); err != nil {
+			return nil, fmt.Errorf("failed to create webhook outbox: %w", err)
+		}
+	}
+	return o, nil
+}
+
+// memoryOutbox loses its events on restart. It keeps no dead letters, which
+// could only be replayed from an outbox_dir; failed events are dropped.
+type memoryOutbox struct {
+	mu      sync.Mutex
+	pending map[string]*Record
+}
+
+func (m *memoryOutbox) Add(r *Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pending[r.ID] = r
+	return nil
+}
+
+func (m *memoryOutbox) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.pending, id)
+	return nil
+}
+
+func (m *memoryOutbox) Fail(r *Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.pending, r.ID)
+	return errNoDeadLetters
+}
+
+func (m *memoryOutbox) Pending() ([]*Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return sortRecords(m.pending), nil
+}
+
+func (m *memoryOutbox) HasPending(id string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.pending[id]
+	return ok, nil
+}
+
+func (m *memoryOutbox) Failed() ([]*Record, error) {
+	return nil, nil
+}
+
+func (m *memoryOutbox) UpdateFailed(*Record) error {
+	return errNoDeadLetters
+}
+
+func (m *memoryOutbox) DeleteFailed(string) error {
+	return nil
+}
+
+func sortRecords(records map[string]*Record) []*Record {
+	list := make([]*Record, 0, len(records))
+	for _, r := range records {
+		list = append(list, r)
+	}
+	sortByCreation(list)
+	return list
+}
+
+func sortByCreation(records []*Record) {
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].CreatedAt.Equal(records[j].CreatedAt) {
+			return records[i].ID < records[j].ID
+		}
+		return records[i].CreatedAt.Before(records[j].CreatedAt)
+	})
+}
+
+// Subdirectories of the file outbox
+const (
+	pendingDir = "pending"
+	failedDir  = "failed"
+)
+
+// fileOutbox keeps every event in its own JSON file, in the pending or the
+// failed directory. Files are written with fileutil.WriteFile, so a crash
+// never leaves a half-written event behind.
+type fileOutbox struct {
+	mu  sync.Mutex
+	dir string
+}
+
+func (f *fileOutbox) Add(r *Record) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.write(pendingDir, r)
+}
+
+func (f *fileOutbox) Delete(id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.remove(pendingDir, id)
+}
+
+func (f *fileOutbox) Fail(r *Record) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.write(failedDir, r); err != nil {
+		return err
+	}
+	return f.remove(pendingDir, r.ID)
+}
+
+func (f *fileOutbox) Pending() ([]*Record, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.read(pendingDir)
+}
+
+func (f *fileOutbox) HasPending(id string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path, err := f.path(pendingDir, id)
+	if err != nil {
+		return false, err
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to read webhook event: %w", err)
+	}
+	return true, nil
+}
+
+func (f *fileOutbox) Failed() ([]*Record, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.read(failedDir)
+}
+
+func (f *fileOutbox) UpdateFailed(r *Record) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.write(failedDir, r)
+}
+
+func (f *fileOutbox) DeleteFailed(id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.remove(failedDir, id)
+}
+
+func (f *fileOutbox) path(sub, id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || strings.HasPrefix(id, ".") {
+		return "", fmt.Errorf("invalid webhook event ID %q", id)
+	}
+	return filepath.Join(f.dir, sub, id+".json"), nil
+}
+
+func (f *fileOutbox) write(sub string, r *Record) error {
+	path, err := f.path(sub, r.ID)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook event: %w", err)
+	}
+	if err := fileutil.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to store webhook event: %w", err)
+	}
+	return nil
+}
+
+func (f *fileOutbox) remove(sub, id string) error {
+	path, err := f.path(sub, id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove webhook event: %w", err)
+	}
+	return nil
+}
+
+func (f *fileOutbox) read(sub string) ([]*Record, error) {
+	dir := filepath.Join(f.dir, sub)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhook outbox: %w", err)
+	}
+
+	var records []*Record
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read webhook event: %w", err)
+		}
+		var r Record
+		if err := json.Unmarshal(data, &r); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal webhook event %s: %w", entry.Name(), err)
+		}
+		records = append(records, &r)
+	}
+	sortByCreation(records)
+	return records, nil
+}
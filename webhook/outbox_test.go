@@ -0,0 +1,315 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testRecord returns a delivery of a submission of the form "ticket"
+func testRecord(t *testing.T, id string, createdAt time.Time, data map[string]interface{}) *Record {
+	t.Helper()
+	r, err := newRecord(Event{SchemaVersion: SchemaVersion, Type: FormSubmitted, Event: "ticket", Timestamp: createdAt, Data: data}, id, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestFileOutbox(t *testing.T) {
+	dir := t.TempDir()
+	outbox, err := NewOutbox(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := testRecord(t, "a", time.Unix(1, 0).UTC(), map[string]interface{}{"title": "hello"})
+	second := testRecord(t, "b", time.Unix(2, 0).UTC(), nil)
+	for _, r := range []*Record{second, first} {
+		if err := outbox.Add(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	first.LastError = "status=500"
+	if err := outbox.Fail(first); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new outbox on the same directory sees the same events
+	reopened, err := NewOutbox(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending, err := reopened.Pending()
+	if err != nil || len(pending) != 1 || pending[0].ID != "b" {
+		t.Fatalf("got pending %v, %v, want b", pending, err)
+	}
+	failed, err := reopened.Failed()
+	if err != nil || len(failed) != 1 || failed[0].ID != "a" || failed[0].LastError != "status=500" {
+		t.Fatalf("got failed %v, %v, want a", failed, err)
+	}
+	if e, err := failed[0].Event(); err != nil || e.Data.(map[string]interface{})["title"] != "hello" {
+		t.Errorf("got event %+v, %v, want the event data", e, err)
+	}
+
+	if err := reopened.DeleteFailed("a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := reopened.Delete("b"); err != nil {
+		t.Fatal(err)
+	}
+	pending, _ = reopened.Pending()
+	failed, _ = reopened.Failed()
+	if len(pending) != 0 || len(failed) != 0 {
+		t.Errorf("got %d pending and %d failed events, want none", len(pending), len(failed))
+	}
+
+	if err := reopened.Add(&Record{ID: "../escape"}); err == nil {
+		t.Error("expected an error for an ID with a path")
+	}
+}
+
+func TestWorkerOutbox(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusBadRequest)
+	var received atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	cfg := &Config{Enabled: true, URL: srv.URL, WorkersCount: 1, QueueSize: 10, OutboxDir: dir}
+
+	// Rejected events are moved to the dead-letter store
+	Workers = nil
+	if err := NewWebhookWorker(cfg); err != nil {
+		t.Fatal(err)
+	}
+	Workers.Enqueue(newTestSession())
+	if err := Workers.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	failed, err := ListFailed(cfg)
+	if err != nil || len(failed) != 1 {
+		t.Fatalf("got failed %v, %v, want one event", failed, err)
+	}
+	if e, err := failed[0].Event(); err != nil || failed[0].Attempts != 1 || failed[0].LastError == "" || e.Event != "ticket" {
+		t.Errorf("unexpected dead-lettered event %+v, %v", failed[0], err)
+	}
+
+	// Replaying delivers it once the receiver accepts it
+	status.Store(http.StatusOK)
+	results, err := Replay(context.Background(), cfg, nil)
+	if err != nil || len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("got %v, %v, want the event delivered", results, err)
+	}
+	if failed, _ := ListFailed(cfg); len(failed) != 0 {
+		t.Errorf("got %d failed events after the replay, want none", len(failed))
+	}
+	if _, err := Replay(context.Background(), cfg, []string{"unknown"}); err == nil {
+		t.Error("expected an error for an unknown event ID")
+	}
+	if got := received.Load(); got != 2 {
+		t.Errorf("got %d requests, want 2", got)
+	}
+}
+
+func TestReplayKeepsPayload(t *testing.T) {
+	// Written by a newer version, with a field this one does not know
+	payload := `{"schema_version":1,"type":"form.submitted","submission_id":"s-1","event":"ticket","form":"ticket","chat_id":9007199254740993,"timestamp":"2025-01-02T03:04:05Z","data":{"count":12345678901234567},"future_field":{"a":1}}`
+
+	for _, tt := range []struct {
+		format string
+		want   string
+	}{
+		{JSONFormat, payload},
+		{CloudEventsFormat, `{"specversion":"1.0","id":"delivery-1","source":"/forms/ticket","type":"form.submitted","subject":"s-1","time":"2025-01-02T03:04:05Z","datacontenttype":"application/json","data":` + payload + `}`},
+	} {
+		t.Run(tt.format, func(t *testing.T) {
+			var body []byte
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ = io.ReadAll(r.Body)
+			}))
+			defer srv.Close()
+
+			dir := t.TempDir()
+			outbox, err := NewOutbox(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := &Record{ID: "delivery-1", Payload: json.RawMessage(payload), CreatedAt: time.Unix(1, 0).UTC()}
+			if err := outbox.Add(r); err != nil {
+				t.Fatal(err)
+			}
+			if err := outbox.Fail(r); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg := &Config{Enabled: true, URL: srv.URL, Format: tt.format, WorkersCount: 1, QueueSize: 10, OutboxDir: dir}
+			results, err := Replay(context.Background(), cfg, nil)
+			if err != nil || len(results) != 1 || results[0].Err != nil {
+				t.Fatalf("got %v, %v, want the event delivered", results, err)
+			}
+			if string(body) != tt.want {
+				t.Errorf("got body\n%s\nwant\n%s", body, tt.want)
+			}
+		})
+	}
+}
+
+func TestPendingEventsAreRecovered(t *testing.T) {
+	var received atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(DeliveryIDHeader) == "left-over" {
+			received.Add(1)
+		}
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	outbox, err := NewOutbox(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := outbox.Add(testRecord(t, "left-over", time.Now(), nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	Workers = nil
+	if err := NewWebhookWorker(&Config{Enabled: true, URL: srv.URL, WorkersCount: 1, QueueSize: 10, OutboxDir: dir}); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for received.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := Workers.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if received.Load() != 1 {
+		t.Fatalf("got %d deliveries of the left-over event, want 1", received.Load())
+	}
+	if pending, _ := outbox.Pending(); len(pending) != 0 {
+		t.Errorf("got %d pending events, want none", len(pending))
+	}
+}
+
+func TestEnqueueDoesNotBlock(t *testing.T) {
+	dir := t.TempDir()
+	// No worker takes events from the unbuffered queue
+	Workers = nil
+	if err := NewWebhookWorker(&Config{Enabled: true, URL: "http://127.0.0.1:1", OutboxDir: dir, OutboxPollInterval: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		Workers.Enqueue(newTestSession())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Enqueue blocked on the full queue")
+	}
+
+	outbox, _ := NewOutbox(dir)
+	if pending, _ := outbox.Pending(); len(pending) != 1 {
+		t.Errorf("got %d pending events, want the event kept in the outbox", len(pending))
+	}
+	if err := Workers.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPollDoesNotRedeliver(t *testing.T) {
+	var mu sync.Mutex
+	deliveries := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		deliveries[r.Header.Get(DeliveryIDHeader)]++
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	// The poll read the event before a worker delivered it
+	w := newTestWorker(&Config{Enabled: true, URL: srv.URL, QueueSize: 10, OutboxDir: t.TempDir()})
+	r := testRecord(t, "delivered", time.Now(), nil)
+	if err := w.outbox.Add(r); err != nil {
+		t.Fatal(err)
+	}
+	stale, _ := w.outbox.Pending()
+	w.deliver(r)
+	w.mu.RLock()
+	w.tryQueue(stale[0], true)
+	w.mu.RUnlock()
+	if len(w.queue) != 0 {
+		t.Fatal("expected the delivered event not to be queued again")
+	}
+
+	// Polls running while the workers deliver
+	Workers = nil
+	if err := NewWebhookWorker(&Config{Enabled: true, URL: srv.URL, WorkersCount: 4, QueueSize: 5, OutboxPollInterval: time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	polls := make(chan struct{})
+	go func() {
+		defer close(polls)
+		for i := 0; i < 200; i++ {
+			Workers.(*worker).queuePending()
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		Workers.Enqueue(newTestSession())
+	}
+	<-polls
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		pending, _ := Workers.(*worker).outbox.Pending()
+		if len(pending) == 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := Workers.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(deliveries) != 101 {
+		t.Errorf("got %d delivered events, want 101", len(deliveries))
+	}
+	for id, n := range deliveries {
+		if n != 1 {
+			t.Errorf("delivery %s was sent %d times", id, n)
+		}
+	}
+}
+
+func TestMemoryOutboxDropsFailedEvents(t *testing.T) {
+	outbox, err := NewOutbox("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := testRecord(t, "a", time.Now(), nil)
+	if err := outbox.Add(r); err != nil {
+		t.Fatal(err)
+	}
+	if err := outbox.Fail(r); err == nil {
+		t.Error("expected an error for dead-lettering without outbox_dir")
+	}
+	pending, _ := outbox.Pending()
+	failed, _ := outbox.Failed()
+	if len(pending) != 0 || len(failed) != 0 {
+		t.Errorf("got %d pending and %d failed events, want none kept", len(pending), len(failed))
+	}
+}
@@ -0,0 +1,95 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ReplayResult is the outcome of sending a dead-lettered event again
+type ReplayResult struct {
+	Record *Record
+	Err    error // Nil if the event was delivered
+}
+
+// openDeadLetters opens the durable outbox of the config
+func openDeadLetters(cfg *Config) (Outbox, error) {
+	if cfg == nil || cfg.OutboxDir == "" {
+		return nil, fmt.Errorf("webhook.outbox_dir is not set, failed events are only kept in the memory of the running bot")
+	}
+	return NewOutbox(cfg.OutboxDir)
+}
+
+// ListFailed returns the events of the dead-letter store, oldest first
+func ListFailed(cfg *Config) ([]*Record, error) {
+	outbox, err := openDeadLetters(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return outbox.Failed()
+}
+
+// Replay sends the dead-lettered events with the given IDs again, or all of
+// them if ids is empty, using the retry policy of the config. Delivered events
+// are removed from the dead-letter store; the others stay with the new error.
+func Replay(ctx context.Context, cfg *Config, ids []string) ([]ReplayResult, error) {
+	outbox, err := openDeadLetters(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.Enabled {
+		return nil, fmt.Errorf("webhook is disabled in the config")
+	}
+	failed, err := outbox.Failed()
+	if err != nil {
+		return nil, err
+	}
+
+	records := failed
+	if len(ids) > 0 {
+		byID := make(map[string]*Record, len(failed))
+		for _, r := range failed {
+			byID[r.ID] = r
+		}
+		records = make([]*Record, 0, len(ids))
+		for _, id := range ids {
+			r, ok := byID[id]
+			if !ok {
+				return nil, fmt.Errorf("event %s is not in the dead-letter store", id)
+			}
+			records = append(records, r)
+		}
+	}
+
+	w, err := newWorker(cfg)
+	if err != nil {
+		return nil, err
+	}
+	w.ctx, w.cancel = context.WithCancel(ctx)
+	defer w.cancel()
+
+	results := make([]ReplayResult, 0, len(records))
+	for _, r := range records {
+		e, err := r.Event()
+		if err != nil {
+			results = append(results, ReplayResult{Record: r, Err: err})
+			continue
+		}
+		attempts, err := w.send(e, r.Payload)
+		r.Attempts += attempts
+		if err == nil {
+			err = outbox.DeleteFailed(r.ID)
+		} else {
+			r.LastError = err.Error()
+			r.FailedAt = time.Now().UTC()
+			if updateErr := outbox.UpdateFailed(r); updateErr != nil {
+				err = fmt.Errorf("%w (and failed to update the dead-letter store: %v)", err, updateErr)
+			}
+		}
+		results = append(results, ReplayResult{Record: r, Err: err})
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return results, nil
+}
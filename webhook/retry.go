@@ -0,0 +1,176 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Headers sent with every attempt of a delivery
+const (
+	DeliveryIDHeader = "X-Delivery-ID"      // Same for all attempts of an event, for idempotency
+	AttemptHeader    = "X-Delivery-Attempt" // Number of the attempt, starting at 1
+)
+
+// Network errors that can be retried
+const (
+	TimeoutError    = "timeout"    // The request or connection timed out
+	ConnectionError = "connection" // The connection was refused, reset or closed early
+	DNSError        = "dns"        // The host name could not be resolved
+)
+
+// Defaults of the retry policy
+const (
+	defaultMaxAttempts = 5
+	defaultBaseDelay   = time.Second
+	defaultMaxDelay    = time.Minute
+	defaultJitter      = 0.2
+)
+
+var defaultRetryStatusCodes = []int{
+	http.StatusRequestTimeout,
+	http.StatusTooEarly,
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+var defaultRetryNetworkErrors = []string{TimeoutError, ConnectionError, DNSError}
+
+// randFloat returns a random number in [0, 1) for the jitter
+var randFloat = rand.Float64
+
+// RetryConfig controls how failed deliveries are retried. Without a retry
+// section all defaults apply; in a retry section, unset attempts and delays
+// get their defaults while an unset jitter means no jitter.
+type RetryConfig struct {
+	MaxAttempts   int           `mapstructure:"max_attempts"`   // Attempts per event including the first; 1 disables retries
+	BaseDelay     time.Duration `mapstructure:"base_delay"`     // Delay before the first retry, doubled for each further one
+	MaxDelay      time.Duration `mapstructure:"max_delay"`      // Upper bound of every delay, including Retry-After
+	Jitter        float64       `mapstructure:"jitter"`         // Random share of a delay from 0 to 1, e.g. 0.2 is ±20%
+	StatusCodes   []int         `mapstructure:"status_codes"`   // Response statuses to retry
+	NetworkErrors []string      `mapstructure:"network_errors"` // Network errors to retry: "timeout", "connection", "dns"
+}
+
+// newRetryPolicy fills in the defaults of the retry config
+func newRetryPolicy(cfg *RetryConfig) RetryConfig {
+	if cfg == nil {
+		return RetryConfig{
+			MaxAttempts:   defaultMaxAttempts,
+			BaseDelay:     defaultBaseDelay,
+			MaxDelay:      defaultMaxDelay,
+			Jitter:        defaultJitter,
+			StatusCodes:   defaultRetryStatusCodes,
+			NetworkErrors: defaultRetryNetworkErrors,
+		}
+	}
+
+	policy := *cfg
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = defaultMaxAttempts
+	}
+	if policy.BaseDelay <= 0 {
+		policy.BaseDelay = defaultBaseDelay
+	}
+	if policy.MaxDelay <= 0 {
+		policy.MaxDelay = defaultMaxDelay
+	}
+	policy.Jitter = min(max(policy.Jitter, 0), 1)
+	if policy.StatusCodes == nil {
+		policy.StatusCodes = defaultRetryStatusCodes
+	}
+	if policy.NetworkErrors == nil {
+		policy.NetworkErrors = defaultRetryNetworkErrors
+	}
+	return policy
+}
+
+// StatusError is returned for a delivery the receiver answered with a status
+// of 300 or above
+type StatusError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // From the Retry-After header, 0 if missing
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("webhook failed: status=%d, response=%s", e.StatusCode, e.Body)
+}
+
+// retryable reports whether a failed attempt may be repeated
+func (p RetryConfig) retryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return slices.Contains(p.StatusCodes, statusErr.StatusCode)
+	}
+	kind := networkErrorKind(err)
+	return kind != "" && slices.Contains(p.NetworkErrors, kind)
+}
+
+// delay returns how long to wait after the failed attempt. Delays grow
+// exponentially from BaseDelay; a Retry-After of the receiver is waited
+// instead if it is longer. No delay exceeds MaxDelay.
+func (p RetryConfig) delay(attempt int, err error) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < attempt && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if p.Jitter > 0 {
+		d = time.Duration(float64(d) * (1 + p.Jitter*(2*randFloat()-1)))
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > d {
+		d = statusErr.RetryAfter
+	}
+	return min(d, p.MaxDelay)
+}
+
+// networkErrorKind classifies errors of the HTTP client, "" if the error
+// is not a network error, e.g. an invalid URL or certificate
+func networkErrorKind(err error) string {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled):
+		return ""
+	case errors.As(err, &dnsErr):
+		return DNSError
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return TimeoutError
+	case errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE),
+		errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return ConnectionError
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return ConnectionError
+	}
+	return ""
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as a date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0)
+	}
+	return 0
+}
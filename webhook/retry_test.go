@@ -0,0 +1,190 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSendWebhookRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		retry        *RetryConfig
+		wantAttempts int
+		wantErr      bool
+	}{
+		{name: "Success", statuses: []int{200}, wantAttempts: 1},
+		{name: "Retried until success", statuses: []int{502, 503, 200}, wantAttempts: 3},
+		{name: "Client error is not retried", statuses: []int{400}, wantAttempts: 1, wantErr: true},
+		{name: "Gives up after max attempts", statuses: []int{500, 500, 500, 500}, retry: &RetryConfig{MaxAttempts: 3, BaseDelay: time.Millisecond}, wantAttempts: 3, wantErr: true},
+		{name: "Configured status codes", statuses: []int{409, 200}, retry: &RetryConfig{BaseDelay: time.Millisecond, StatusCodes: []int{409}}, wantAttempts: 2},
+		{name: "Retries disabled", statuses: []int{502, 200}, retry: &RetryConfig{MaxAttempts: 1}, wantAttempts: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var ids, attempts []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				ids = append(ids, r.Header.Get(DeliveryIDHeader))
+				attempts = append(attempts, r.Header.Get(AttemptHeader))
+				w.WriteHeader(tt.statuses[len(ids)-1])
+			}))
+			defer srv.Close()
+
+			retry := tt.retry
+			if retry == nil {
+				retry = &RetryConfig{BaseDelay: time.Millisecond}
+			}
+			w := newTestWorker(&Config{Enabled: true, URL: srv.URL, Retry: retry})
+			err := w.SendWebhook(Event{Event: "ticket", DeliveryID: "delivery-1"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+
+			if len(ids) != tt.wantAttempts {
+				t.Fatalf("got %d attempts, want %d", len(ids), tt.wantAttempts)
+			}
+			for i := range ids {
+				if ids[i] != "delivery-1" {
+					t.Errorf("attempt %d: got delivery ID %q, want the same ID on every attempt", i+1, ids[i])
+				}
+				if attempts[i] != strconv.Itoa(i+1) {
+					t.Errorf("attempt %d: got attempt header %q", i+1, attempts[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSendWebhookRetriesNetworkErrors(t *testing.T) {
+	// A closed listener refuses connections
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := "http://" + ln.Addr().String()
+	ln.Close()
+
+	w := newTestWorker(&Config{Enabled: true, URL: url, Retry: &RetryConfig{MaxAttempts: 2, BaseDelay: time.Millisecond}})
+	err = w.SendWebhook(Event{Event: "ticket"})
+	if err == nil || networkErrorKind(err) != ConnectionError {
+		t.Fatalf("got %v, want a connection error", err)
+	}
+	if !strings.HasPrefix(err.Error(), "giving up after 2 attempt(s)") {
+		t.Errorf("got %v, want it to be retried", err)
+	}
+
+	w = newTestWorker(&Config{Enabled: true, URL: url, Retry: &RetryConfig{MaxAttempts: 2, NetworkErrors: []string{DNSError}}})
+	if err := w.SendWebhook(Event{Event: "ticket"}); err == nil || !strings.HasPrefix(err.Error(), "giving up after 1 attempt(s)") {
+		t.Errorf("got %v, want no retry for connection errors", err)
+	}
+}
+
+func TestSendWebhookCancelledRetry(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	w := newTestWorker(&Config{Enabled: true, URL: srv.URL, Retry: &RetryConfig{BaseDelay: time.Hour, MaxDelay: time.Hour}})
+	time.AfterFunc(20*time.Millisecond, w.cancel)
+	err := w.SendWebhook(Event{Event: "ticket"})
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("got %v, want the last status error", err)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	randFloat = func() float64 { return 1 } // Largest jitter
+	defer func() { randFloat = rand.Float64 }()
+
+	policy := newRetryPolicy(&RetryConfig{BaseDelay: time.Second, MaxDelay: 10 * time.Second})
+	tests := []struct {
+		name    string
+		policy  RetryConfig
+		attempt int
+		err     error
+		want    time.Duration
+	}{
+		{name: "First retry", policy: policy, attempt: 1, want: time.Second},
+		{name: "Exponential", policy: policy, attempt: 3, want: 4 * time.Second},
+		{name: "Capped", policy: policy, attempt: 10, want: 10 * time.Second},
+		{name: "Retry-After", policy: policy, attempt: 1, err: &StatusError{StatusCode: 429, RetryAfter: 5 * time.Second}, want: 5 * time.Second},
+		{name: "Retry-After shorter than backoff", policy: policy, attempt: 3, err: &StatusError{StatusCode: 429, RetryAfter: time.Second}, want: 4 * time.Second},
+		{name: "Retry-After capped", policy: policy, attempt: 1, err: &StatusError{StatusCode: 503, RetryAfter: time.Hour}, want: 10 * time.Second},
+		{name: "Jitter", policy: newRetryPolicy(&RetryConfig{BaseDelay: time.Second, Jitter: 0.5}), attempt: 1, want: 1500 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.delay(tt.attempt, tt.err); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{"-5", 0},
+		{now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := parseRetryAfter(tt.value, now); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNetworkErrorKind(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "DNS", err: fmt.Errorf("send: %w", &net.DNSError{Err: "no such host", Name: "example.invalid"}), want: DNSError},
+		{name: "Timeout", err: fmt.Errorf("send: %w", context.DeadlineExceeded), want: TimeoutError},
+		{name: "Dial", err: &net.OpError{Op: "dial", Err: errors.New("refused")}, want: ConnectionError},
+		{name: "Cancelled", err: context.Canceled, want: ""},
+		{name: "Other", err: errors.New("unsupported protocol scheme"), want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := networkErrorKind(tt.err); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func newTestWorker(cfg *Config) *worker {
+	w, err := newWorker(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return w
+}
@@ -0,0 +1,96 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers of the "hmac" auth type. The delivery ID is sent in
+// DeliveryIDHeader.
+const (
+	SignatureHeader = "X-Webhook-Signature" // "v1=<hex>", one per active secret, comma separated
+	TimestampHeader = "X-Webhook-Timestamp" // Unix time of signing in seconds
+)
+
+// signatureVersion prefixes every signature, so the scheme can change later
+const signatureVersion = "v1"
+
+// DefaultTolerance is how far the timestamp of a request may be from the
+// current time when Verify is called without a tolerance
+const DefaultTolerance = 5 * time.Minute
+
+// Errors of Verify
+var (
+	ErrMissingSignature = errors.New("webhook: missing signature, timestamp or delivery ID header")
+	ErrInvalidTimestamp = errors.New("webhook: timestamp is outside the tolerance")
+	ErrInvalidSignature = errors.New("webhook: no signature matches")
+)
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<delivery ID>.<body>"
+// with the secret
+func Sign(secret string, timestamp int64, deliveryID string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write([]byte(deliveryID))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// signRequest sets the signature headers of the "hmac" auth type, signed
+// with every secret so receivers can rotate secrets
+func signRequest(header http.Header, secrets []string, deliveryID string, body []byte, now time.Time) {
+	timestamp := now.Unix()
+	signatures := make([]string, 0, len(secrets))
+	for _, secret := range secrets {
+		signatures = append(signatures, signatureVersion+"="+Sign(secret, timestamp, deliveryID, body))
+	}
+	header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	header.Set(SignatureHeader, strings.Join(signatures, ","))
+}
+
+// Verify checks that a webhook request was signed by the bot with one of the
+// secrets and that its timestamp is at most tolerance away from now, which
+// rejects replays of old requests. body must be the raw request body. Store
+// the X-Delivery-ID of accepted requests to also reject replays within the
+// tolerance and retried deliveries that already succeeded.
+func Verify(header http.Header, body []byte, secrets []string, tolerance time.Duration) error {
+	return verifyAt(header, body, secrets, tolerance, time.Now())
+}
+
+func verifyAt(header http.Header, body []byte, secrets []string, tolerance time.Duration, now time.Time) error {
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+
+	signatures := header.Get(SignatureHeader)
+	deliveryID := header.Get(DeliveryIDHeader)
+	timestamp, err := strconv.ParseInt(header.Get(TimestampHeader), 10, 64)
+	if signatures == "" || deliveryID == "" || err != nil {
+		return ErrMissingSignature
+	}
+
+	signedAt := time.Unix(timestamp, 0)
+	if signedAt.Before(now.Add(-tolerance)) || signedAt.After(now.Add(tolerance)) {
+		return fmt.Errorf("%w: signed at %s", ErrInvalidTimestamp, signedAt.UTC().Format(time.RFC3339))
+	}
+
+	for _, secret := range secrets {
+		expected := []byte(Sign(secret, timestamp, deliveryID, body))
+		for _, signature := range strings.Split(signatures, ",") {
+			version, value, ok := strings.Cut(strings.TrimSpace(signature), "=")
+			if ok && version == signatureVersion && hmac.Equal([]byte(value), expected) {
+				return nil
+			}
+		}
+	}
+	return ErrInvalidSignature
+}
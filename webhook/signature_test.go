@@ -0,0 +1,111 @@
+package webhook
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSignedDelivery(t *testing.T) {
+	var header http.Header
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	w := newTestWorker(&Config{Enabled: true, URL: srv.URL, Auth: Auth{Type: "hmac", Secrets: []string{"current", "previous"}}})
+	if err := w.SendWebhook(Event{Event: "ticket", Data: map[string]interface{}{"name": "Ada"}, DeliveryID: "delivery-1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		secrets []string
+		body    string
+		wantErr error
+	}{
+		{name: "Current secret", secrets: []string{"current"}},
+		{name: "Previous secret during rotation", secrets: []string{"previous"}},
+		{name: "One of several secrets", secrets: []string{"unknown", "current"}},
+		{name: "Wrong secret", secrets: []string{"unknown"}, wantErr: ErrInvalidSignature},
+		{name: "No secrets", wantErr: ErrInvalidSignature},
+		{name: "Tampered body", secrets: []string{"current"}, body: `{"event":"ticket","data":{"name":"Eve"}}`, wantErr: ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := body
+			if tt.body != "" {
+				b = []byte(tt.body)
+			}
+			if err := Verify(header, b, tt.secrets, 0); !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyHeaders(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"event":"ticket"}`)
+	signed := func(signedAt time.Time, deliveryID string) http.Header {
+		header := http.Header{}
+		header.Set(DeliveryIDHeader, deliveryID)
+		signRequest(header, []string{"secret"}, deliveryID, body, signedAt)
+		return header
+	}
+
+	tests := []struct {
+		name      string
+		header    http.Header
+		tolerance time.Duration
+		wantErr   error
+	}{
+		{name: "Fresh", header: signed(now, "delivery-1")},
+		{name: "Within tolerance", header: signed(now.Add(-4*time.Minute), "delivery-1")},
+		{name: "Expired", header: signed(now.Add(-6*time.Minute), "delivery-1"), wantErr: ErrInvalidTimestamp},
+		{name: "From the future", header: signed(now.Add(6*time.Minute), "delivery-1"), wantErr: ErrInvalidTimestamp},
+		{name: "Custom tolerance", header: signed(now.Add(-time.Minute), "delivery-1"), tolerance: 30 * time.Second, wantErr: ErrInvalidTimestamp},
+		{name: "Missing headers", header: http.Header{}, wantErr: ErrMissingSignature},
+		{name: "Invalid timestamp", header: func() http.Header {
+			h := signed(now, "delivery-1")
+			h.Set(TimestampHeader, "yesterday")
+			return h
+		}(), wantErr: ErrMissingSignature},
+		{name: "Replaced timestamp", header: func() http.Header {
+			h := signed(now.Add(-time.Hour), "delivery-1")
+			h.Set(TimestampHeader, strconv.FormatInt(now.Unix(), 10))
+			return h
+		}(), wantErr: ErrInvalidSignature},
+		{name: "Replaced delivery ID", header: func() http.Header {
+			h := signed(now, "delivery-1")
+			h.Set(DeliveryIDHeader, "delivery-2")
+			return h
+		}(), wantErr: ErrInvalidSignature},
+		{name: "Unknown version", header: func() http.Header {
+			h := signed(now, "delivery-1")
+			h.Set(SignatureHeader, "v2="+Sign("secret", now.Unix(), "delivery-1", body))
+			return h
+		}(), wantErr: ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := verifyAt(tt.header, body, []string{"secret"}, tt.tolerance, now); !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHMACRequiresSecrets(t *testing.T) {
+	if _, err := newWorker(&Config{Enabled: true, URL: "http://localhost", Auth: Auth{Type: "hmac"}}); err == nil {
+		t.Error("expected an error for the hmac auth type without secrets")
+	}
+}
@@ -2,142 +2,460 @@ package webhook
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"go-tg-support-ticket/form"
+	"github.com/google/uuid"
 	"go-tg-support-ticket/logger"
+	"go-tg-support-ticket/session"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 )
 
+// maxResponseBody limits how much of a response is read for error messages
+const maxResponseBody = 4 << 10
+
 // Config holds the webhook settings
 type Config struct {
 	Enabled      bool   `mapstructure:"enabled"`
-	URL          string `mapstructure:"url"`
-	Auth         Auth   `mapstructure:"auth"`
+	URL          string `mapstructure:"url"`  // URL of the default endpoint
+	Auth         Auth   `mapstructure:"auth"` // Auth of the default endpoint
 	WorkersCount int    `mapstructure:"workers_count"`
 	QueueSize    int    `mapstructure:"queue_size"`
+
+	Retry  *RetryConfig `mapstructure:"retry"`  // Retry policy of the default endpoint; the defaults apply if not set
+	Events []string     `mapstructure:"events"` // Event types of the default endpoint; only form.submitted if not set
+	Format string       `mapstructure:"format"` // Payload format of the default endpoint; json if not set
+
+	Endpoints []Endpoint `mapstructure:"endpoints"` // Further receivers with their own settings and filters
+
+	OutboxDir          string        `mapstructure:"outbox_dir"`           // Keeps events until delivered; in memory only if empty
+	OutboxPollInterval time.Duration `mapstructure:"outbox_poll_interval"` // How often events left in the outbox are queued again
 }
 
+// defaultOutboxPollInterval is used if outbox_poll_interval is not set
+const defaultOutboxPollInterval = 10 * time.Second
+
 type Auth struct {
-	Type     string `yaml:"type"`     // "none", "bearer", "basic"
-	Token    string `yaml:"token"`    // Bearer token
-	Username string `yaml:"username"` // Basic auth username
-	Password string `yaml:"password"` // Basic auth password
+	Type     string   `yaml:"type"`     // "none", "bearer", "basic", "hmac"
+	Token    string   `yaml:"token"`    // Bearer token
+	Username string   `yaml:"username"` // Basic auth username
+	Password string   `yaml:"password"` // Basic auth password
+	Secrets  []string `yaml:"secrets"`  // HMAC secrets; every request is signed with each of them
 }
 
-// Event represents the event data to be sent
+// Event represents the event data to be sent. Its JSON is the payload
+// schema of SchemaVersion.
 type Event struct {
-	Event string      `json:"event"`
-	Data  interface{} `json:"data"`
+	SchemaVersion int           `json:"schema_version"`
+	Type          string        `json:"type"`          // Event type, e.g. FormSubmitted
+	SubmissionID  string        `json:"submission_id"` // Same for all events of a session; the ticket is stored with it
+	Event         string        `json:"event"`         // Name of the form
+	Form          string        `json:"form"`          // Slug of the form
+	FormVersion   string        `json:"form_version,omitempty"`
+	ChatID        int64         `json:"chat_id"`
+	User          *session.User `json:"user,omitempty"`         // Telegram user filling in the form
+	Timestamp     time.Time     `json:"timestamp"`              // When the event happened
+	SubmittedAt   *time.Time    `json:"submitted_at,omitempty"` // Set for form.submitted
+	Data          interface{}   `json:"data"`                   // Depends on the event type
+	Fields        []Field       `json:"fields,omitempty"`       // Answers with their labels and types, for form.submitted
+
+	URL     string            `json:"-"` // Form specific URL; the configured URL is used if empty
+	Answers map[string]string `json:"-"` // Answers on the path of the form, for the `when` conditions of endpoints
+
+	Endpoint   string `json:"-"` // Name of the endpoint to send to; the default endpoint if empty
+	DeliveryID string `json:"-"` // Identifies the event across delivery attempts
 }
 
 // worker manages concurrent webhook requests
 type worker struct {
-	cfg    *Config
-	queue  chan Event
-	wg     sync.WaitGroup
-	client *http.Client
+	cfg       *Config
+	endpoints []*endpoint
+	queue     chan *Record
+	wg        sync.WaitGroup
+	outbox    Outbox
+
+	mu     sync.RWMutex // Guards closed against concurrent Enqueue calls
+	closed bool
+	stop   chan struct{} // Stops polling the outbox
+
+	queuedMu sync.Mutex
+	queued   map[string]bool // IDs of outbox events in the queue or being delivered
+
+	ctx    context.Context // Cancelled when the shutdown deadline passes
+	cancel context.CancelFunc
 }
 
 type WorkerInterface interface {
-	Enqueue(form *form.Form)
+	Enqueue(s *session.Session) // Publishes the form.submitted event of the session
+	Publish(e Event)
+	Shutdown(ctx context.Context) error
 }
 
 var Workers WorkerInterface
 
-// NewWebhookWorker initializes a worker pool
-func NewWebhookWorker(cfg *Config) {
-	w := worker{
-		cfg: cfg,
+// NewWebhookWorker initializes a worker pool. Events left in the outbox by
+// a previous run are queued again.
+func NewWebhookWorker(cfg *Config) error {
+	if !cfg.Enabled {
+		return nil
+	}
+	w, err := newWorker(cfg)
+	if err != nil {
+		return err
+	}
+
+	// Start workers
+	for i := 0; i < cfg.WorkersCount; i++ {
+		w.wg.Add(1)
+		go w.processQueue()
 	}
-	if cfg.Enabled {
-		w.queue = make(chan Event, cfg.QueueSize)
-		w.client = &http.Client{Timeout: 10 * time.Second}
+	w.stop = make(chan struct{})
+	go w.pollOutbox()
+	Workers = w
+	return nil
+}
 
-		// Start workers
-		for i := 0; i < cfg.WorkersCount; i++ {
-			w.wg.Add(1)
-			go w.processQueue()
-		}
-		Workers = &w
+// newWorker creates a worker that is not started
+func newWorker(cfg *Config) (*worker, error) {
+	endpoints, err := newEndpoints(cfg)
+	if err != nil {
+		return nil, err
+	}
+	outbox, err := NewOutbox(cfg.OutboxDir)
+	if err != nil {
+		return nil, err
 	}
+	w := &worker{
+		cfg:       cfg,
+		endpoints: endpoints,
+		queue:     make(chan *Record, cfg.QueueSize),
+		outbox:    outbox,
+		queued:    make(map[string]bool),
+	}
+	w.ctx, w.cancel = context.WithCancel(context.Background())
+	return w, nil
+}
+
+// Enqueue publishes the form.submitted event of the session
+func (w *worker) Enqueue(s *session.Session) {
+	w.Publish(NewEvent(s))
 }
 
-// Enqueue adds a webhook request to the queue
-func (w *worker) Enqueue(form *form.Form) {
+// Publish stores one delivery of the event per matching endpoint in the
+// outbox and queues them. It never blocks: if the queue is full, deliveries
+// wait in the outbox for the next poll.
+func (w *worker) Publish(e Event) {
 	if w != nil {
-		event := buildEvent(form)
-		w.queue <- event
+		var records []*Record
+		stored := make(map[string]bool)
+		for _, ep := range w.endpoints {
+			if !ep.matches(e) {
+				continue
+			}
+			r, err := newRecord(e, uuid.NewString(), ep.Name)
+			if err != nil {
+				logger.PrintLog(e.ChatID, fmt.Sprintf("failed to publish webhook event to %s", ep.Name), err)
+				continue
+			}
+			if err := w.outbox.Add(r); err != nil {
+				// Still try to deliver it from memory
+				logger.PrintLog(e.ChatID, fmt.Sprintf("failed to store webhook delivery %s in the outbox", r.ID), err)
+			} else {
+				stored[r.ID] = true
+			}
+			records = append(records, r)
+		}
+
+		w.mu.RLock()
+		defer w.mu.RUnlock()
+		for _, r := range records {
+			if w.closed {
+				logger.PrintLog(e.ChatID, "webhook worker is shut down, event stays in the outbox", fmt.Errorf("delivery %s to %s not sent", r.ID, r.Endpoint))
+				continue
+			}
+			// A poll may have delivered a stored event already
+			if !w.tryQueue(r, stored[r.ID]) {
+				logger.PrintLog(e.ChatID, "webhook queue is full, event stays in the outbox", fmt.Errorf("delivery %s to %s delayed", r.ID, r.Endpoint))
+			}
+		}
+	}
+}
+
+// tryQueue queues the outbox event unless it is queued already or the queue
+// is full; w.mu must be read locked. With pending, events stored in the
+// outbox are only queued if they are still pending, not if a worker delivered
+// or dead-lettered them in the meantime.
+func (w *worker) tryQueue(r *Record, pending bool) bool {
+	w.queuedMu.Lock()
+	defer w.queuedMu.Unlock()
+	if w.queued[r.ID] {
+		return true
+	}
+	if pending {
+		// Workers remove the event from the outbox before they unmark it
+		ok, err := w.outbox.HasPending(r.ID)
+		if err != nil {
+			logger.PrintLog(0, fmt.Sprintf("failed to check webhook delivery %s in the outbox", r.ID), err)
+			return true
+		}
+		if !ok {
+			return true
+		}
+	}
+	select {
+	case w.queue <- r:
+		w.queued[r.ID] = true
+		return true
+	default:
+		return false
+	}
+}
+
+// pollOutbox queues the pending events of the outbox that are not queued,
+// right away and then every poll interval
+func (w *worker) pollOutbox() {
+	interval := w.cfg.OutboxPollInterval
+	if interval <= 0 {
+		interval = defaultOutboxPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		w.queuePending()
+		select {
+		case <-ticker.C:
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+func (w *worker) queuePending() {
+	records, err := w.outbox.Pending()
+	if err != nil {
+		logger.PrintLog(0, "failed to read the webhook outbox", err)
+		return
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	for _, r := range records {
+		if w.closed || !w.tryQueue(r, true) {
+			return
+		}
 	}
 }
 
-func buildEvent(form *form.Form) Event {
+// NewEvent builds the form.submitted event of the session, whose data holds
+// the answers by field name
+func NewEvent(s *session.Session) Event {
 	data := make(map[string]interface{})
-	for _, field := range form.Fields {
-		data[field.Name] = field.UserValue
+	fields := make([]Field, 0, len(s.Form.Fields))
+	for _, field := range s.Fields() {
+		value := FieldValue(s, field, field.UserValue)
+		data[field.Name] = value
+		f := Field{Name: field.Name, Label: field.Label, Type: field.Type, Value: value, Skipped: field.Skipped}
+		if field.IsNull() {
+			f.Value = nil
+		}
+		fields = append(fields, f)
 	}
-	return Event{Event: form.FormName, Data: data}
+
+	e := NewSessionEvent(s, FormSubmitted, data)
+	e.SubmittedAt = &e.Timestamp
+	e.Fields = fields
+	return e
 }
 
 // processQueue processes webhook requests in background workers
 func (w *worker) processQueue() {
 	defer w.wg.Done()
-	for req := range w.queue {
-		err := w.SendWebhook(req)
-		if err != nil {
-			logger.PrintLog(0, "failed to send webhook", err)
+	for r := range w.queue {
+		w.deliver(r) // Removes the event from the outbox unless it stays pending
+		w.queuedMu.Lock()
+		delete(w.queued, r.ID)
+		w.queuedMu.Unlock()
+	}
+}
+
+// deliver sends the outbox event and removes it from the outbox once it is
+// delivered or moves it to the dead-letter store once all attempts failed.
+// Events whose delivery was cancelled by the shutdown stay pending.
+func (w *worker) deliver(r *Record) {
+	e, err := r.Event()
+	if err != nil {
+		logger.PrintLog(0, fmt.Sprintf("failed to read webhook delivery %s", r.ID), err)
+		w.fail(0, r, err)
+		return
+	}
+
+	attempts, err := w.send(e, r.Payload)
+	r.Attempts += attempts
+	if err == nil {
+		if err := w.outbox.Delete(r.ID); err != nil {
+			logger.PrintLog(e.ChatID, fmt.Sprintf("failed to remove webhook delivery %s from the outbox", r.ID), err)
 		}
+		return
+	}
+
+	logger.PrintLog(e.ChatID, fmt.Sprintf("failed to send webhook delivery %s to %s", r.ID, r.Endpoint), err)
+	if w.ctx.Err() != nil {
+		return
 	}
+	w.fail(e.ChatID, r, err)
 }
 
-// Shutdown waits for all workers to finish
-func (w *worker) Shutdown() {
-	close(w.queue)
-	w.wg.Wait()
+// fail moves the outbox event to the dead-letter store
+func (w *worker) fail(chatID int64, r *Record, err error) {
+	r.LastError = err.Error()
+	r.FailedAt = time.Now().UTC()
+	if err := w.outbox.Fail(r); err != nil {
+		logger.PrintLog(chatID, fmt.Sprintf("failed to move webhook delivery %s to the dead-letter store", r.ID), err)
+	}
 }
 
-// SendWebhook sends event data if webhook is enabled
+// Shutdown stops accepting events and waits until the queued ones are sent.
+// If ctx is done first, requests in flight and waiting retries are cancelled;
+// the events not sent stay in the outbox for the next start.
+func (w *worker) Shutdown(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.stop)
+		close(w.queue)
+	}
+	w.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		w.cancel()
+		return nil
+	case <-ctx.Done():
+		pending := len(w.queue)
+		w.cancel()
+		return fmt.Errorf("%d queued webhook events were not sent and stay in the outbox: %w", pending, ctx.Err())
+	}
+}
+
+// SendWebhook sends event data to its endpoint if webhook is enabled. Failed
+// attempts are retried according to the retry policy of the endpoint until
+// the shutdown deadline.
 func (w *worker) SendWebhook(e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook data: %w", err)
+	}
+	_, err = w.send(e, data)
+	return err
+}
+
+// endpoint returns the endpoint with the given name, the default one if name
+// is empty
+func (w *worker) endpoint(name string) (*endpoint, error) {
+	if name == "" {
+		name = DefaultEndpoint
+	}
+	for _, ep := range w.endpoints {
+		if ep.Name == name {
+			return ep, nil
+		}
+	}
+	return nil, fmt.Errorf("webhook endpoint %q is not configured", name)
+}
+
+// send is SendWebhook for the event whose JSON is data; it also returns the
+// number of attempts made
+func (w *worker) send(e Event, data json.RawMessage) (int, error) {
+	ep, err := w.endpoint(e.Endpoint)
+	if err != nil {
+		return 0, err
+	}
+	url := ep.url(e)
+
 	// Check if webhook is enabled
-	if !w.cfg.Enabled || w.cfg.URL == "" {
-		return nil // Webhook is disabled, do nothing
+	if !w.cfg.Enabled || url == "" {
+		return 0, nil // Webhook is disabled, do nothing
 	}
 
-	payload, err := json.Marshal(e)
+	payload, contentType, err := ep.encode(e, data)
 	if err != nil {
-		return fmt.Errorf("failed to marshal webhook data: %w", err)
+		return 0, fmt.Errorf("failed to marshal webhook data: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", w.cfg.URL, bytes.NewBuffer(payload))
+	for attempt := 1; ; attempt++ {
+		err := w.attempt(ep, url, payload, contentType, e.DeliveryID, attempt)
+		if err == nil {
+			logger.PrintLog(e.ChatID, fmt.Sprintf("webhook delivery %s to %s attempt %d/%d succeeded", e.DeliveryID, ep.Name, attempt, ep.retry.MaxAttempts), nil)
+			return attempt, nil
+		}
+		if attempt >= ep.retry.MaxAttempts || !ep.retry.retryable(err) {
+			return attempt, fmt.Errorf("giving up after %d attempt(s): %w", attempt, err)
+		}
+
+		delay := ep.retry.delay(attempt, err)
+		logger.PrintLog(e.ChatID, fmt.Sprintf("webhook delivery %s to %s attempt %d/%d failed, retrying in %s", e.DeliveryID, ep.Name, attempt, ep.retry.MaxAttempts, delay), err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-w.ctx.Done():
+			timer.Stop()
+			return attempt, fmt.Errorf("retry cancelled after %d attempt(s): %w", attempt, err)
+		}
+	}
+}
+
+// attempt makes one delivery attempt to the endpoint
+func (w *worker) attempt(ep *endpoint, url string, payload []byte, contentType, deliveryID string, attempt int) error {
+	req, err := http.NewRequestWithContext(w.ctx, "POST", url, bytes.NewReader(payload))
 	if err != nil {
 		return fmt.Errorf("failed to create webhook request: %w", err)
 	}
 
 	// Set headers
-	req.Header.Set("Content-Type", "application/json")
+	for name, value := range ep.Headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set(DeliveryIDHeader, deliveryID)
+	req.Header.Set(AttemptHeader, strconv.Itoa(attempt))
 
 	// Handle authentication if enabled
-	if strings.ToLower(w.cfg.Auth.Type) == "bearer" {
-		req.Header.Set("Authorization", "Bearer "+w.cfg.Auth.Token)
-	} else if strings.ToLower(w.cfg.Auth.Type) == "basic" {
-		req.SetBasicAuth(w.cfg.Auth.Username, w.cfg.Auth.Password)
+	if strings.ToLower(ep.Auth.Type) == "bearer" {
+		req.Header.Set("Authorization", "Bearer "+ep.Auth.Token)
+	} else if strings.ToLower(ep.Auth.Type) == "basic" {
+		req.SetBasicAuth(ep.Auth.Username, ep.Auth.Password)
+	} else if strings.ToLower(ep.Auth.Type) == "hmac" {
+		// Signed on every attempt, so the timestamp stays fresh
+		signRequest(req.Header, ep.Auth.Secrets, deliveryID, payload, time.Now())
 	}
 
-	// Send request using worker's HTTP client
-	resp, err := w.client.Do(req)
+	// Send request using the endpoint's HTTP client
+	resp, err := ep.client.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to send webhook: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// Read response
-	body, _ := io.ReadAll(resp.Body)
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
 	if resp.StatusCode >= 300 {
-		return fmt.Errorf("webhook failed: status=%d, response=%s", resp.StatusCode, body)
+		return &StatusError{
+			StatusCode: resp.StatusCode,
+			Body:       string(body),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
 	}
 
 	return nil
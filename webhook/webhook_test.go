@@ -0,0 +1,68 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"go-tg-support-ticket/form"
+	"go-tg-support-ticket/session"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestSession() *session.Session {
+	f := &form.Form{FormName: "ticket", Fields: []form.Field{{Name: "title", Type: "text"}}}
+	s := session.New(1, f)
+	s.Record("hello")
+	return s
+}
+
+func TestShutdownDrainsQueue(t *testing.T) {
+	var received atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+	}))
+	defer srv.Close()
+
+	Workers = nil
+	NewWebhookWorker(&Config{Enabled: true, URL: srv.URL, WorkersCount: 1, QueueSize: 10})
+	for i := 0; i < 5; i++ {
+		Workers.Enqueue(newTestSession())
+	}
+
+	if err := Workers.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if got := received.Load(); got != 5 {
+		t.Errorf("got %d events, want 5", got)
+	}
+
+	// Events after shutdown are dropped instead of panicking
+	Workers.Enqueue(newTestSession())
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	Workers = nil
+	NewWebhookWorker(&Config{Enabled: true, URL: srv.URL, WorkersCount: 1, QueueSize: 10})
+	Workers.Enqueue(newTestSession())
+	Workers.Enqueue(newTestSession())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := Workers.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want deadline exceeded", err)
+	}
+}